@@ -0,0 +1,186 @@
+// Package action runs the pre, main and post phases of an action from a single binary.
+//
+// Declare the same binary for all phases in action.yml, and dispatch them with Run:
+//
+//	func main() {
+//		action.Run(action.Hooks{
+//			Main: func(ctx context.Context) error {
+//				dir, err := os.MkdirTemp("", "workspace")
+//				if err != nil {
+//					return err
+//				}
+//				return action.AddCleanup("remove-workspace", dir)
+//			},
+//			Cleanups: map[string]action.Cleanup{
+//				"remove-workspace": func(ctx context.Context, dir string) error {
+//					return os.RemoveAll(dir)
+//				},
+//			},
+//		})
+//	}
+package action
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+
+	"github.com/actions-go/toolkit/core"
+)
+
+// Phase is a step of the action lifecycle
+type Phase string
+
+const (
+	// PhasePre is the phase run before the main one, when action.yml declares `runs.pre`
+	PhasePre Phase = "pre"
+	// PhaseMain is the main phase of the action
+	PhaseMain Phase = "main"
+	// PhasePost is the phase run at the end of the job, when action.yml declares `runs.post`
+	PhasePost Phase = "post"
+
+	// isPreState is saved by the pre phase, so that the next run is detected as the main phase
+	isPreState = "isPre"
+	// isPostState is saved by the main phase, so that the next run is detected as the post phase
+	isPostState = "isPost"
+	// cleanupsState holds the cleanups registered with AddCleanup
+	cleanupsState = "actionsGoCleanups"
+)
+
+var (
+	exit = os.Exit
+
+	cleanupsAccess sync.Mutex
+	cleanups       []registeredCleanup
+	cleanupsLoaded bool
+)
+
+// Cleanup is run in the post phase with the state given to AddCleanup
+type Cleanup func(ctx context.Context, state string) error
+
+// Hooks holds the functions implementing each phase of the action.
+// A phase whose hook is nil does nothing, except the post phase running the registered cleanups.
+type Hooks struct {
+	// Pre is run first when set, action.yml must then declare `runs.pre`
+	Pre func(ctx context.Context) error
+	// Main is the main phase of the action
+	Main func(ctx context.Context) error
+	// Post is run after all the job steps, before the cleanups registered with AddCleanup
+	Post func(ctx context.Context) error
+	// Cleanups maps the names given to AddCleanup to their implementation
+	Cleanups map[string]Cleanup
+}
+
+type registeredCleanup struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// Phase returns the phase currently running, detected from the state saved by the previous phases
+func (h Hooks) Phase() Phase {
+	if core.GetState(isPostState) != "" {
+		return PhasePost
+	}
+	if h.Pre != nil && core.GetState(isPreState) == "" {
+		return PhasePre
+	}
+	return PhaseMain
+}
+
+// Run runs the hook of the current phase and exits the process with the code implied by core.Status.
+//
+// Run installs the signal handler of core.HandleSignals: hooks are given the root context, cancelled when the workflow
+// is cancelled.
+// A hook returning an error or panicking marks the action as failed.
+// In the post phase, the cleanups registered with AddCleanup are run in the reverse order of their registration,
+// even when the Post hook fails.
+func Run(h Hooks) {
+	exit(run(core.HandleSignals(), h))
+}
+
+func run(ctx context.Context, h Hooks) int {
+	switch h.Phase() {
+	case PhasePre:
+		core.SaveState(isPreState, "true")
+		runHook(ctx, "pre", h.Pre)
+	case PhaseMain:
+		core.SaveState(isPostState, "true")
+		if h.Main == nil {
+			core.SetFailed("no main hook defined")
+			break
+		}
+		runHook(ctx, "main", h.Main)
+	case PhasePost:
+		if h.Post != nil {
+			runHook(ctx, "post", h.Post)
+		}
+		runCleanups(ctx, h.Cleanups)
+	}
+	if err := core.Flush(); err != nil {
+		core.Warningf("unable to flush the output: %v", err)
+	}
+	return core.Status()
+}
+
+func runHook(ctx context.Context, name string, f func(ctx context.Context) error) {
+	defer func() {
+		if r := recover(); r != nil {
+			core.Debug(string(debug.Stack()))
+			core.SetFailedf("%s panicked: %v", name, r)
+		}
+	}()
+	if err := f(ctx); err != nil {
+		core.SetFailedErr(err)
+	}
+}
+
+// AddCleanup registers the cleanup name to be run in the post phase with state.
+// The cleanup must be declared in Hooks.Cleanups, and action.yml must declare `runs.post`.
+func AddCleanup(name, state string) error {
+	cleanupsAccess.Lock()
+	defer cleanupsAccess.Unlock()
+	if err := loadCleanups(); err != nil {
+		return err
+	}
+	cleanups = append(cleanups, registeredCleanup{Name: name, State: state})
+	return core.SaveStateJSON(cleanupsState, cleanups)
+}
+
+// loadCleanups reads the cleanups registered by previous phases, it must be called with cleanupsAccess held
+func loadCleanups() error {
+	if cleanupsLoaded {
+		return nil
+	}
+	if err := core.GetStateJSON(cleanupsState, &cleanups); err != nil {
+		return err
+	}
+	cleanupsLoaded = true
+	return nil
+}
+
+func runCleanups(ctx context.Context, declared map[string]Cleanup) {
+	cleanupsAccess.Lock()
+	err := loadCleanups()
+	registered := append([]registeredCleanup(nil), cleanups...)
+	cleanupsAccess.Unlock()
+	if err != nil {
+		core.SetFailedErr(err)
+		return
+	}
+	for i := len(registered) - 1; i >= 0; i-- {
+		c := registered[i]
+		f, ok := declared[c.Name]
+		if !ok {
+			core.SetFailedf("cleanup %s is not declared", c.Name)
+			continue
+		}
+		runHook(ctx, "cleanup "+c.Name, func(ctx context.Context) error {
+			if err := f(ctx, c.State); err != nil {
+				return fmt.Errorf("cleanup %s: %w", c.Name, err)
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/actions-go/toolkit/core"
+	"github.com/actions-go/toolkit/core/coretest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runPhase runs h in a new sandbox, as a new process would, with the state saved by the previous phases
+func runPhase(t *testing.T, h Hooks, state map[string]string) (*coretest.Action, int) {
+	t.Helper()
+	a := coretest.New(t)
+	for k, v := range state {
+		a.SetState(k, v)
+	}
+	cleanupsAccess.Lock()
+	cleanups = nil
+	cleanupsLoaded = false
+	cleanupsAccess.Unlock()
+	return a, run(context.Background(), h)
+}
+
+func merge(maps ...map[string]string) map[string]string {
+	r := map[string]string{}
+	for _, m := range maps {
+		for k, v := range m {
+			r[k] = v
+		}
+	}
+	return r
+}
+
+func TestRunLifecycle(t *testing.T) {
+	calls := []string{}
+	h := Hooks{
+		Pre: func(ctx context.Context) error {
+			calls = append(calls, "pre")
+			return AddCleanup("remove", "pre-dir")
+		},
+		Main: func(ctx context.Context) error {
+			calls = append(calls, "main")
+			require.NoError(t, AddCleanup("remove", "main-dir"))
+			return AddCleanup("logout", "")
+		},
+		Post: func(ctx context.Context) error {
+			calls = append(calls, "post")
+			return errors.New("post failed")
+		},
+		Cleanups: map[string]Cleanup{
+			"remove": func(ctx context.Context, state string) error {
+				calls = append(calls, "remove "+state)
+				return nil
+			},
+			"logout": func(ctx context.Context, state string) error {
+				calls = append(calls, "logout")
+				panic("unexpected")
+			},
+		},
+	}
+
+	var state map[string]string
+	t.Run("pre", func(t *testing.T) {
+		assert.Equal(t, PhasePre, h.Phase())
+		a, code := runPhase(t, h, nil)
+		assert.Equal(t, core.StatusSuccess, code)
+		state = a.State()
+	})
+	t.Run("main", func(t *testing.T) {
+		a, code := runPhase(t, h, state)
+		assert.Equal(t, PhaseMain, h.Phase())
+		assert.Equal(t, core.StatusSuccess, code)
+		state = merge(state, a.State())
+	})
+	t.Run("post", func(t *testing.T) {
+		a, code := runPhase(t, h, state)
+		assert.Equal(t, PhasePost, h.Phase())
+		assert.Equal(t, core.StatusFailed, code)
+		messages := []string{}
+		for _, annotation := range a.Annotations() {
+			messages = append(messages, annotation.Message)
+		}
+		assert.Equal(t, []string{"post failed", "cleanup logout panicked: unexpected"}, messages)
+	})
+	assert.Equal(t, []string{"pre", "main", "post", "logout", "remove main-dir", "remove pre-dir"}, calls)
+}
+
+func TestRunWithoutPre(t *testing.T) {
+	called := false
+	h := Hooks{Main: func(ctx context.Context) error {
+		called = true
+		panic("boom")
+	}}
+	assert.Equal(t, PhaseMain, h.Phase())
+	a, code := runPhase(t, h, nil)
+	assert.True(t, called)
+	assert.Equal(t, core.StatusFailed, code)
+	assert.Equal(t, "true", a.State()[isPostState])
+	require.Len(t, a.Annotations(), 1)
+	assert.Equal(t, "main panicked: boom", a.Annotations()[0].Message)
+
+	a, code = runPhase(t, Hooks{}, map[string]string{
+		isPostState:   "true",
+		cleanupsState: `[{"name":"unknown","state":""}]`,
+	})
+	assert.Equal(t, core.StatusFailed, code)
+	assert.Equal(t, "cleanup unknown is not declared", a.Annotations()[0].Message)
+}
+
+func TestRunExits(t *testing.T) {
+	code := -1
+	exit = func(c int) { code = c }
+	t.Cleanup(func() { exit = os.Exit })
+	coretest.New(t)
+	Run(Hooks{Main: func(ctx context.Context) error { return errors.New("failed") }})
+	assert.Equal(t, core.StatusFailed, code)
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -48,7 +49,7 @@ func destination(options *DownloadToolOptions) string {
 }
 
 func ensureDestDir(dest string) error {
-	fmt.Println(dest)
+	core.Debugf("Downloading to %s", dest)
 	destDir := filepath.Dir(dest)
 	if destDir == "" {
 		return nil
@@ -77,14 +78,18 @@ func ensureDestNotExists(dest string) error {
 	return nil
 }
 
-func copyURL(dest io.Writer, source string) error {
+func copyURL(ctx context.Context, dest io.Writer, source string) error {
 	wrapError := func(err error, format string, args ...interface{}) error {
 		return fmt.Errorf("failed to download "+source+" "+format+" : %v", append(args, err)...)
 	}
 	if dest == nil {
 		return wrapError(fmt.Errorf("destination should not be null"), "")
 	}
-	resp, err := http.Get(source)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return wrapError(err, "invalid request")
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return wrapError(err, "download failed")
 	}
@@ -161,7 +166,7 @@ func noRel(path string) string {
 	return filepath.Join(p...)
 }
 
-func cache(source, target string, options CacheOptions) (string, error) {
+func cache(ctx context.Context, source, target string, options CacheOptions) (string, error) {
 	destFolder := toolPath(options)
 	completeMarker := destFolder + ".complete"
 	wrapError := func(err error, format string, args ...interface{}) (string, error) {
@@ -171,6 +176,16 @@ func cache(source, target string, options CacheOptions) (string, error) {
 	if err != nil {
 		return wrapError(err, "invalid options")
 	}
+	// Never leave a partially populated cache behind
+	removeOnShutdown := core.OnShutdown("remove partial cache "+destFolder, func(ctx context.Context) error {
+		return os.RemoveAll(destFolder)
+	})
+	defer removeOnShutdown()
+	defer func() {
+		if err != nil {
+			os.RemoveAll(destFolder)
+		}
+	}()
 	core.Debugf(`destination file %s`, destFolder)
 	err = createEmptyCache(destFolder)
 	if err != nil {
@@ -183,6 +198,12 @@ func cache(source, target string, options CacheOptions) (string, error) {
 	// Ensure provided arguments are namespaced to the destFolder
 	target = noRel(target)
 	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if info.IsDir() {
 			return nil
 		}
@@ -231,15 +252,17 @@ func copyFile(dest, src string) error {
 }
 
 // CacheFile caches a downloaded file (GUID) and installs it
-// into the tool cache with a given targetName
+// into the tool cache with a given targetName.
+// The copy is interrupted when the action is cancelled, see core.Context, and the partial cache is removed.
 func CacheFile(source, target string, options CacheOptions) (string, error) {
-	return cache(source, target, options)
+	return cache(core.Context(), source, target, options)
 }
 
 // CacheDir caches a directory and installs it into the tool cacheDir
-// with a given targetName
+// with a given targetName.
+// The copy is interrupted when the action is cancelled, see core.Context, and the partial cache is removed.
 func CacheDir(source string, options CacheOptions) (string, error) {
-	return cache(source, "", options)
+	return cache(core.Context(), source, "", options)
 }
 
 // ListAllCachedVersions discovers all versions available in cache
@@ -297,8 +320,15 @@ func FindVersion(options CacheOptions) (string, error) {
 	return "", fmt.Errorf("could not find any cached version for %s matching %s", options.Tool, options.Version)
 }
 
-// DownloadTool Download a tool from an url and stream it into a file
+// DownloadTool Download a tool from an url and stream it into a file.
+// The download is interrupted when the action is cancelled, see core.Context.
 func DownloadTool(url string, options *DownloadToolOptions) (string, error) {
+	return DownloadToolContext(core.Context(), url, options)
+}
+
+// DownloadToolContext downloads a tool from an url and streams it into a file, until ctx is done.
+// The destination file is removed when the download fails or the action is shut down, see core.OnShutdown.
+func DownloadToolContext(ctx context.Context, url string, options *DownloadToolOptions) (string, error) {
 	// TODO
 	//   const http = new httpm.HttpClient(userAgent, [], {
 	//     allowRetries: true,
@@ -320,7 +350,17 @@ func DownloadTool(url string, options *DownloadToolOptions) (string, error) {
 	if err != nil {
 		return wrapError(err, "failed to create destination file %s", dest)
 	}
-	if err := copyURL(out, url); err != nil {
+	removeOnShutdown := core.OnShutdown("remove partial download "+dest, func(ctx context.Context) error {
+		out.Close()
+		return os.Remove(dest)
+	})
+	defer removeOnShutdown()
+	err = copyURL(ctx, out, url)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dest)
 		return wrapError(err, "failed to write file %s", dest)
 	}
 	if options != nil && options.FileMode != 0 {
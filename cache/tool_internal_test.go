@@ -2,6 +2,7 @@ package cache
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -150,22 +151,33 @@ func TestCacheDir(t *testing.T) {
 	assert.FileExists(t, filepath.Join(path, "core.go"))
 }
 
+func TestCacheDirCancelled(t *testing.T) {
+	cacheRoot = "test-cache-root-" + uuid.New().String()
+	defer os.RemoveAll(cacheRoot)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := cache(ctx, "../core", "", CacheOptions{Tool: "some-other-tool", Version: "0.1.0"})
+	assert.Error(t, err)
+	assert.NoDirExists(t, filepath.Join(cacheRoot, "some-other-tool", "0.1.0"))
+}
+
 func TestCopyURL(t *testing.T) {
 	data := "hello-world"
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(data)) }))
 	defer s.Close()
 	b := bytes.NewBuffer(nil)
-	assert.NoError(t, copyURL(b, s.URL))
+	assert.NoError(t, copyURL(context.Background(), b, s.URL))
 	assert.Equal(t, data, b.String())
 
-	assert.Error(t, copyURL(nil, s.URL))
+	assert.Error(t, copyURL(context.Background(), nil, s.URL))
 
-	assert.Error(t, copyURL(b, "this is not a URL"))
+	assert.Error(t, copyURL(context.Background(), b, "this is not a URL"))
 
-	assert.Error(t, copyURL(writerInError{}, s.URL))
+	assert.Error(t, copyURL(context.Background(), writerInError{}, s.URL))
 
 	s.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotAcceptable) })
-	assert.Error(t, copyURL(bytes.NewBuffer(nil), s.URL))
+	assert.Error(t, copyURL(context.Background(), bytes.NewBuffer(nil), s.URL))
 }
 
 type writerInError struct {
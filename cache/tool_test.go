@@ -1,6 +1,7 @@
 package cache_test
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -34,6 +35,24 @@ func TestDownloadTool(t *testing.T) {
 	assert.Equal(t, data, string(bytes))
 }
 
+func TestDownloadToolContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer s.Close()
+	cacheDir := "./temp-" + uuid.New().String()
+	defer os.RemoveAll(cacheDir)
+	dest := filepath.Join(cacheDir, "tool")
+
+	_, err := cache.DownloadToolContext(ctx, s.URL, &cache.DownloadToolOptions{Destination: dest})
+	assert.ErrorContains(t, err, context.Canceled.Error())
+	assert.NoFileExists(t, dest)
+}
+
 func TestGetCachedToolOrDownload(t *testing.T) {
 	data := "hello-world"
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(data)) }))
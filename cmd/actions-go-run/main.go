@@ -0,0 +1,492 @@
+// Command actions-go-run runs a Go action locally, emulating the GitHub Actions runner.
+//
+// It reads the action.yml metadata, prepares the GITHUB_* and RUNNER_* environment, the inputs and the
+// file commands in a temporary directory, and runs the action command for each of its pre, main and post phases.
+// The event payload is parsed with github.ParseActionEnv to set the ref, commit, actor and repository it describes.
+// The workflow commands issued by the action are rendered for humans, and the outputs, exported variables,
+// PATH additions, annotations and step summary, rendered as text, are reported once the action completes.
+//
+// Usage:
+//
+//	actions-go-run [flags] [command [args...]]
+//
+// The command defaults to `go run <action directory>`. For example:
+//
+//	actions-go-run --input who-to-greet=octocat --event event.json --event-name pull_request
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/actions-go/toolkit/core"
+	"github.com/actions-go/toolkit/github"
+	"github.com/actions-go/toolkit/metadata"
+)
+
+var inputExpression = regexp.MustCompile(`\$\{\{\s*inputs\.([A-Za-z0-9_-]+)\s*\}\}`)
+
+// inputFlags collects the repeated --input name=value flags
+type inputFlags map[string]string
+
+func (f inputFlags) String() string {
+	return ""
+}
+
+func (f inputFlags) Set(v string) error {
+	name, value, ok := strings.Cut(v, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expecting name=value, got %q", v)
+	}
+	f[name] = value
+	return nil
+}
+
+// runner runs the phases of an action, carrying the state, exported variables and paths from one phase to the next
+type runner struct {
+	action  *metadata.Action
+	command []string
+	root    string
+	env     map[string]string
+	signals chan os.Signal
+
+	state       map[string]string
+	exported    map[string]string
+	paths       []string
+	outputs     map[string]string
+	annotations []core.Event
+	summary     strings.Builder
+}
+
+func main() {
+	signals := make(chan os.Signal, 1)
+	// Let the action handle the cancellation, and report what it did
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	os.Exit(run(os.Args[1:], os.Stdout, signals))
+}
+
+func run(args []string, stdout io.Writer, signals chan os.Signal) int {
+	fs := flag.NewFlagSet("actions-go-run", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	inputs := inputFlags{}
+	fs.Var(inputs, "input", "set the action input `name=value`, may be repeated")
+	actionDir := fs.String("action", ".", "the `directory` holding the action.yml file")
+	eventPath := fs.String("event", "", "the `path` of the JSON webhook payload triggering the workflow")
+	eventName := fs.String("event-name", "push", "the `name` of the event triggering the workflow")
+	repository := fs.String("repository", "actions-go/local", "the `owner/repo` repository running the workflow")
+	debug := fs.Bool("debug", false, "enable the debug messages")
+	color := fs.Bool("color", os.Getenv("NO_COLOR") == "", "colorize the output")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	console := core.NewConsoleSink(stdout, core.ConsoleOptions{Color: *color, Debug: *debug})
+	previous := core.CurrentSink()
+	core.SetSink(console)
+	defer core.SetSink(previous)
+
+	action, err := metadata.LoadDir(*actionDir)
+	if err != nil {
+		core.Error(err.Error())
+		return 1
+	}
+	root, err := os.MkdirTemp("", "actions-go-run-")
+	if err != nil {
+		core.Errorf("unable to create the temporary directory: %v", err)
+		return 1
+	}
+	defer os.RemoveAll(root)
+
+	r := &runner{
+		action:   action,
+		command:  fs.Args(),
+		root:     root,
+		signals:  signals,
+		state:    map[string]string{},
+		exported: map[string]string{},
+		outputs:  map[string]string{},
+	}
+	if len(r.command) == 0 {
+		r.command = []string{"go", "run", *actionDir}
+	}
+	r.env, err = prepareEnv(root, *actionDir, *eventPath, *eventName, *repository, *debug)
+	if err != nil {
+		core.Error(err.Error())
+		return 1
+	}
+	r.setInputs(inputs)
+
+	repositorySet := false
+	fs.Visit(func(f *flag.Flag) { repositorySet = repositorySet || f.Name == "repository" })
+	var ctx github.ActionContext
+	withEnv(r.env, func() { ctx = github.ParseActionEnv() })
+	for k, v := range eventEnv(ctx, !repositorySet) {
+		r.env[k] = v
+	}
+	core.Infof("Running %s on %s event for %s", action.Name, ctx.EventName, r.env["GITHUB_REPOSITORY"])
+
+	failed := false
+	if action.Runs.Pre != "" || action.Runs.PreEntrypoint != "" {
+		failed = !r.runPhase("pre")
+	}
+	if !failed {
+		failed = !r.runPhase("main")
+	}
+	if action.Runs.Post != "" || action.Runs.PostEntrypoint != "" {
+		failed = !r.runPhase("post") || failed
+	}
+	r.report()
+	console.Flush()
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// prepareEnv returns the environment set by the runner for all the steps of a job
+func prepareEnv(root, actionDir, eventPath, eventName, repository string, debug bool) (map[string]string, error) {
+	workspace, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	actionPath, err := filepath.Abs(actionDir)
+	if err != nil {
+		return nil, err
+	}
+	if eventPath == "" {
+		eventPath = filepath.Join(root, "event.json")
+		if err := os.WriteFile(eventPath, []byte("{}"), 0600); err != nil {
+			return nil, fmt.Errorf("unable to write the event payload: %w", err)
+		}
+	} else if eventPath, err = filepath.Abs(eventPath); err != nil {
+		return nil, err
+	}
+	env := map[string]string{
+		"CI":                      "true",
+		"GITHUB_ACTIONS":          "true",
+		"GITHUB_WORKSPACE":        workspace,
+		"GITHUB_ACTION_PATH":      actionPath,
+		"GITHUB_EVENT_NAME":       eventName,
+		"GITHUB_EVENT_PATH":       eventPath,
+		"GITHUB_REPOSITORY":       repository,
+		"GITHUB_REPOSITORY_OWNER": strings.SplitN(repository, "/", 2)[0],
+		"GITHUB_REF":              "refs/heads/main",
+		"GITHUB_REF_NAME":         "main",
+		"GITHUB_SHA":              strings.Repeat("0", 40),
+		"GITHUB_WORKFLOW":         "actions-go-run",
+		"GITHUB_JOB":              "local",
+		"GITHUB_ACTION":           "__run",
+		"GITHUB_ACTOR":            "actions-go-run",
+		"GITHUB_RUN_ID":           "1",
+		"GITHUB_RUN_NUMBER":       "1",
+		"GITHUB_RUN_ATTEMPT":      "1",
+		"GITHUB_SERVER_URL":       "https://github.com",
+		"GITHUB_API_URL":          "https://api.github.com",
+		"GITHUB_GRAPHQL_URL":      "https://api.github.com/graphql",
+		"RUNNER_NAME":             "actions-go-run",
+		"RUNNER_OS":               runnerOS(),
+		"RUNNER_ARCH":             runnerArch(),
+		"RUNNER_TEMP":             filepath.Join(root, "temp"),
+		"RUNNER_TOOL_CACHE":       filepath.Join(root, "tool-cache"),
+	}
+	if debug {
+		env["RUNNER_DEBUG"] = "1"
+	}
+	for _, dir := range []string{env["RUNNER_TEMP"], env["RUNNER_TOOL_CACHE"]} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// eventEnv returns the environment describing the event payload parsed in ctx, like the runner does:
+// the ref and commit of a push or a pull request, the actor, and the repository when useRepository is set
+func eventEnv(ctx github.ActionContext, useRepository bool) map[string]string {
+	env := map[string]string{}
+	payload := ctx.Payload
+	if repo := payload.Repository; useRepository && repo.GetFullName() != "" {
+		env["GITHUB_REPOSITORY"] = repo.GetFullName()
+		env["GITHUB_REPOSITORY_OWNER"] = strings.SplitN(repo.GetFullName(), "/", 2)[0]
+	}
+	if login := payload.Sender.GetLogin(); login != "" {
+		env["GITHUB_ACTOR"] = login
+	}
+	if pr := payload.PullRequest; pr != nil && pr.GetNumber() != 0 {
+		refName := fmt.Sprintf("%d/merge", pr.GetNumber())
+		env["GITHUB_REF"] = "refs/pull/" + refName
+		env["GITHUB_REF_NAME"] = refName
+		env["GITHUB_HEAD_REF"] = pr.GetHead().GetRef()
+		env["GITHUB_BASE_REF"] = pr.GetBase().GetRef()
+		if sha := pr.GetMergeCommitSHA(); sha != "" {
+			env["GITHUB_SHA"] = sha
+		}
+	} else if push := payload.PushEvent; push != nil && push.GetRef() != "" {
+		env["GITHUB_REF"] = push.GetRef()
+		env["GITHUB_REF_NAME"] = strings.TrimPrefix(strings.TrimPrefix(push.GetRef(), "refs/heads/"), "refs/tags/")
+		if sha := push.GetAfter(); sha != "" {
+			env["GITHUB_SHA"] = sha
+		}
+	}
+	return env
+}
+
+func runnerOS() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS"
+	case "windows":
+		return "Windows"
+	}
+	return "Linux"
+}
+
+func runnerArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "X64"
+	case "386":
+		return "X86"
+	case "arm64":
+		return "ARM64"
+	}
+	return strings.ToUpper(runtime.GOARCH)
+}
+
+func inputEnvName(name string) string {
+	return strings.ToUpper("INPUT_" + strings.Replace(name, " ", "_", -1))
+}
+
+// setInputs sets the inputs, applying the defaults declared in action.yml like the runner does
+func (r *runner) setInputs(inputs map[string]string) {
+	for name, value := range inputs {
+		if _, ok := r.action.Input(name); !ok {
+			core.Warningf("Unexpected input '%s', valid inputs are declared in action.yml", name)
+		}
+		r.env[inputEnvName(name)] = value
+	}
+	for name, input := range r.action.Inputs {
+		if _, ok := r.env[inputEnvName(name)]; ok {
+			continue
+		}
+		if input.Default != "" && !strings.Contains(input.Default, "${{") {
+			r.env[inputEnvName(name)] = input.Default
+		} else if input.Required {
+			core.Warningf("Input required and not supplied: %s", name)
+		}
+	}
+	for name, value := range r.action.Runs.Env {
+		r.env[name] = inputExpression.ReplaceAllStringFunc(value, func(expr string) string {
+			return r.env[inputEnvName(inputExpression.FindStringSubmatch(expr)[1])]
+		})
+	}
+}
+
+// runPhase runs the action command for a phase with fresh file commands, and returns whether it succeeded
+func (r *runner) runPhase(phase string) bool {
+	core.StartGroup(phase)
+	defer core.EndGroup()
+
+	files := map[string]string{}
+	dir := filepath.Join(r.root, phase)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		core.Error(err.Error())
+		return false
+	}
+	for _, name := range []string{
+		core.GitHubOutputFilePathEnvName,
+		core.GitHubExportEnvFilePathEnvName,
+		core.GitHubStateFilePathEnvName,
+		core.GitHubPathFilePathEnvName,
+		core.GitHubSummaryPathEnvName,
+	} {
+		files[name] = filepath.Join(dir, strings.ToLower(name))
+		if err := os.WriteFile(files[name], nil, 0600); err != nil {
+			core.Error(err.Error())
+			return false
+		}
+	}
+
+	env := map[string]string{}
+	for k, v := range r.env {
+		env[k] = v
+	}
+	for k, v := range r.exported {
+		env[k] = v
+	}
+	for k, v := range r.state {
+		env["STATE_"+k] = v
+	}
+	for k, v := range files {
+		env[k] = v
+	}
+	if len(r.paths) > 0 {
+		env["PATH"] = strings.Join(append(append([]string{}, r.paths...), os.Getenv("PATH")), string(os.PathListSeparator))
+	}
+
+	err := r.exec(mergeEnv(os.Environ(), env))
+	if collectErr := r.collect(files); collectErr != nil {
+		core.Error(collectErr.Error())
+		return false
+	}
+	if err != nil {
+		core.Errorf("%s phase failed: %v", phase, err)
+		return false
+	}
+	return true
+}
+
+// exec runs the command, rendering its workflow commands
+func (r *runner) exec(env []string) error {
+	cmd := exec.Command(r.command[0], r.command[1:]...)
+	cmd.Env = env
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-r.signals:
+				cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), core.MaxOutputsSize*2)
+	for scanner.Scan() {
+		c, ok := core.ParseCommand(scanner.Text())
+		if !ok {
+			core.Info(scanner.Text())
+			continue
+		}
+		e := core.Event(c)
+		if e.Kind == "add-mask" {
+			core.SetSecret(e.Message)
+			continue
+		}
+		if _, ok := e.Annotation(); ok {
+			r.annotations = append(r.annotations, e)
+		}
+		core.Emit(e)
+	}
+	io.Copy(io.Discard, out)
+	return errors.Join(scanner.Err(), cmd.Wait())
+}
+
+// collect reads the file commands written by a phase
+func (r *runner) collect(files map[string]string) error {
+	for _, f := range []struct {
+		name   string
+		values map[string]string
+	}{
+		{core.GitHubOutputFilePathEnvName, r.outputs},
+		{core.GitHubExportEnvFilePathEnvName, r.exported},
+		{core.GitHubStateFilePathEnvName, r.state},
+	} {
+		values, err := core.ReadFileCommand(files[f.name])
+		if err != nil {
+			return err
+		}
+		for k, v := range values {
+			f.values[k] = v
+		}
+	}
+	paths, err := core.ReadPathFileCommand(files[core.GitHubPathFilePathEnvName])
+	if err != nil {
+		return err
+	}
+	// Like the runner, the last added path comes first
+	for _, p := range paths {
+		r.paths = append([]string{p}, r.paths...)
+	}
+	summary, err := os.ReadFile(files[core.GitHubSummaryPathEnvName])
+	if err != nil {
+		return err
+	}
+	r.summary.Write(summary)
+	return nil
+}
+
+// report prints the annotations and the step summary, and hands the outputs, exported variables and paths
+// to the console that prints them when flushed
+func (r *runner) report() {
+	if len(r.annotations) > 0 {
+		core.StartGroup("Annotations")
+		core.Emit(r.annotations...)
+		core.EndGroup()
+	}
+	if r.summary.Len() > 0 {
+		core.StartGroup("Step summary")
+		core.Info(renderSummary(r.summary.String()))
+		core.EndGroup()
+	}
+	for _, name := range sortedKeys(r.outputs) {
+		core.IssueCommand("set-output", map[string]string{"name": name}, r.outputs[name])
+	}
+	for _, name := range sortedKeys(r.exported) {
+		core.IssueCommand("set-env", map[string]string{"name": name}, r.exported[name])
+	}
+	for _, p := range r.paths {
+		core.IssueCommand("add-path", nil, p)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeEnv overrides the environment base, dropping the inputs and states it may hold
+func mergeEnv(base []string, overrides map[string]string) []string {
+	env := []string{}
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := overrides[name]; ok || strings.HasPrefix(name, "INPUT_") || strings.HasPrefix(name, "STATE_") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	for _, name := range sortedKeys(overrides) {
+		env = append(env, name+"="+overrides[name])
+	}
+	return env
+}
+
+// withEnv runs f with the environment variables set, and restores them afterwards
+func withEnv(env map[string]string, f func()) {
+	for k, v := range env {
+		previous, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+		if ok {
+			defer os.Setenv(k, previous)
+		} else {
+			defer os.Unsetenv(k)
+		}
+	}
+	f()
+}
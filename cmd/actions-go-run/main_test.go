@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/actions-go/toolkit/action"
+	"github.com/actions-go/toolkit/core"
+	"github.com/actions-go/toolkit/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const helperEnvName = "ACTIONS_GO_RUN_TEST_ACTION"
+
+// TestMain runs the test binary as the action when started by actions-go-run
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnvName) == "1" {
+		testAction()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func testAction() {
+	action.Run(action.Hooks{
+		Pre: func(ctx context.Context) error {
+			core.Info("preparing")
+			return action.AddCleanup("log", "from pre")
+		},
+		Main: func(ctx context.Context) error {
+			token, _ := core.GetInput("token")
+			core.SetSecret(token)
+			core.Infof("token is %s", token)
+			core.Group("greet", func() {
+				core.Info(os.Getenv("GREETING"))
+			})
+			core.Warning("be nice", core.AnnotationProperties{File: "main.go", StartLine: 3})
+			core.SetOutput("greeting", os.Getenv("GREETING"))
+			core.ExportVariable("GREETED", "yes")
+			core.AddPath("/opt/greeter")
+			core.AddStepSummary("# Greetings")
+			core.JobSummary.AddTable([][]core.SummaryTableCell{
+				{{Data: "Who", Header: true}, {Data: "Ref", Header: true}},
+				{{Data: "World"}, {Data: os.Getenv("GITHUB_REF")}},
+			}).Write()
+			return nil
+		},
+		Post: func(ctx context.Context) error {
+			core.Infof("post sees GREETED=%s", os.Getenv("GREETED"))
+			return errors.New("post failed")
+		},
+		Cleanups: map[string]action.Cleanup{
+			"log": func(ctx context.Context, state string) error {
+				core.Infof("cleanup %s", state)
+				return nil
+			},
+		},
+	})
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	t.Setenv(helperEnvName, "1")
+	t.Setenv("INPUT_TOKEN", "leaked from the parent environment")
+	event := filepath.Join(t.TempDir(), "event.json")
+	require.NoError(t, os.WriteFile(event, []byte(`{"issue": {"number": 1}, "repository": {"full_name": "octocat/hello"}}`), 0600))
+
+	b := &bytes.Buffer{}
+	code := run([]string{
+		"--action", "testdata",
+		"--color=false",
+		"--input", "token=s3cr3t",
+		"--input", "unknown=value",
+		"--event", event,
+		"--event-name", "issues",
+		os.Args[0],
+	}, b, nil)
+	assert.Equal(t, 1, code)
+	out := b.String()
+	for _, expected := range []string{
+		"warning: Unexpected input 'unknown', valid inputs are declared in action.yml\n",
+		"Running Greeter on issues event for octocat/hello\n",
+		"▸ pre\n  preparing\n",
+		"  token is ***\n",
+		"  ▸ greet\n    Hello World\n",
+		"  warning: main.go:3 be nice\n",
+		"  post sees GREETED=yes\n",
+		"  error: post failed\n",
+		"  cleanup from pre\n",
+		"error: post phase failed: exit status 1\n",
+		"▸ Annotations\n  warning: main.go:3 be nice\n  error: post failed\n",
+		"▸ Step summary\n  # Greetings\n  Who   | Ref\n  ------+----------------\n  World | refs/heads/main\n",
+		"Outputs\n  greeting  Hello World\n",
+		"Environment\n  GREETED  yes\n",
+		"Paths\n  PATH  /opt/greeter\n",
+	} {
+		assert.Contains(t, out, expected)
+	}
+	assert.NotContains(t, out, "s3cr3t")
+	assert.NotContains(t, out, "leaked")
+}
+
+func TestRunInvalidArguments(t *testing.T) {
+	b := &bytes.Buffer{}
+	assert.Equal(t, 2, run([]string{"--input", "no-value"}, b, nil))
+	assert.Contains(t, b.String(), `expecting name=value, got "no-value"`)
+	b.Reset()
+	assert.Equal(t, 1, run([]string{"--color=false", "--action", t.TempDir()}, b, nil))
+	assert.Contains(t, b.String(), "error: ")
+}
+
+func TestMergeEnv(t *testing.T) {
+	assert.Equal(t, []string{"HOME=/root", "A=1", "PATH=/bin"}, mergeEnv(
+		[]string{"HOME=/root", "PATH=/usr/bin", "INPUT_X=1", "STATE_Y=2"},
+		map[string]string{"PATH": "/bin", "A": "1"},
+	))
+}
+
+func TestEventEnv(t *testing.T) {
+	var ctx github.ActionContext
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"ref": "refs/tags/v1.0.0",
+		"after": "0123456789abcdef",
+		"repository": {"full_name": "octocat/hello"},
+		"sender": {"login": "octocat"}
+	}`), &ctx.Payload))
+	assert.Equal(t, map[string]string{
+		"GITHUB_REPOSITORY":       "octocat/hello",
+		"GITHUB_REPOSITORY_OWNER": "octocat",
+		"GITHUB_ACTOR":            "octocat",
+		"GITHUB_REF":              "refs/tags/v1.0.0",
+		"GITHUB_REF_NAME":         "v1.0.0",
+		"GITHUB_SHA":              "0123456789abcdef",
+	}, eventEnv(ctx, true))
+
+	ctx = github.ActionContext{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"pull_request": {"number": 12, "merge_commit_sha": "fedcba", "head": {"ref": "feature"}, "base": {"ref": "main"}},
+		"repository": {"full_name": "octocat/hello"}
+	}`), &ctx.Payload))
+	assert.Equal(t, map[string]string{
+		"GITHUB_REF":      "refs/pull/12/merge",
+		"GITHUB_REF_NAME": "12/merge",
+		"GITHUB_HEAD_REF": "feature",
+		"GITHUB_BASE_REF": "main",
+		"GITHUB_SHA":      "fedcba",
+	}, eventEnv(ctx, false))
+
+	assert.Empty(t, eventEnv(github.ActionContext{}, true))
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+	trailingSpace = regexp.MustCompile(`[ \t]+\n`)
+	spaces        = regexp.MustCompile(`\s+`)
+)
+
+// summaryRenderer renders the HTML and Markdown of a step summary as text
+type summaryRenderer struct {
+	out strings.Builder
+	// lists holds the next number of each ordered list being rendered, 0 for unordered lists
+	lists []int
+	table [][]string
+	row   []string
+	// cell buffers the content of the table cell being rendered, if any
+	cell *strings.Builder
+	href string
+	pre  int
+}
+
+// renderSummary renders the step summary as text: headings, lists, tables and details are laid out,
+// other tags and the hidden comments are dropped. Markdown is kept as is.
+func renderSummary(summary string) string {
+	r := &summaryRenderer{}
+	z := html.NewTokenizer(strings.NewReader(summary))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			out := trailingSpace.ReplaceAllString(r.out.String(), "\n")
+			return strings.Trim(blankLines.ReplaceAllString(out, "\n\n"), "\n")
+		case html.TextToken:
+			r.text(string(z.Text()))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			r.start(z.Token())
+		case html.EndTagToken:
+			r.end(z.Token().Data)
+		}
+	}
+}
+
+func (r *summaryRenderer) text(text string) {
+	switch {
+	case r.cell != nil:
+		r.cell.WriteString(text)
+	case r.pre > 0:
+		r.out.WriteString(text)
+	case r.atLineStart():
+		// drops the line breaks between the elements
+		if strings.TrimSpace(text) != "" {
+			r.out.WriteString(strings.TrimLeft(text, "\r\n"))
+		}
+	default:
+		r.out.WriteString(text)
+	}
+}
+
+// write writes s as is, in the table cell being rendered if any
+func (r *summaryRenderer) write(s string) {
+	if r.cell != nil {
+		r.cell.WriteString(s)
+	} else {
+		r.out.WriteString(s)
+	}
+}
+
+func (r *summaryRenderer) atLineStart() bool {
+	out := r.out.String()
+	return out == "" || strings.HasSuffix(out, "\n")
+}
+
+// newline starts a new line, unless already at the start of one
+func (r *summaryRenderer) newline() {
+	if r.cell != nil {
+		r.write(" ")
+	} else if !r.atLineStart() {
+		r.out.WriteString("\n")
+	}
+}
+
+func (r *summaryRenderer) start(t html.Token) {
+	switch t.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.newline()
+		r.write(strings.Repeat("#", int(t.Data[1]-'0')) + " ")
+	case "p", "div", "details", "blockquote":
+		r.newline()
+	case "br":
+		r.write("\n")
+	case "hr":
+		r.newline()
+		r.write("---\n")
+	case "pre":
+		r.newline()
+		r.pre++
+	case "summary":
+		r.newline()
+		r.write("▸ ")
+	case "ul":
+		r.newline()
+		r.lists = append(r.lists, 0)
+	case "ol":
+		r.newline()
+		r.lists = append(r.lists, 1)
+	case "li":
+		r.newline()
+		bullet := "- "
+		if n := len(r.lists); n > 0 {
+			r.write(strings.Repeat("  ", n-1))
+			if r.lists[n-1] > 0 {
+				bullet = strconv.Itoa(r.lists[n-1]) + ". "
+				r.lists[n-1]++
+			}
+		}
+		r.write(bullet)
+	case "table":
+		r.newline()
+		r.table = nil
+	case "tr":
+		r.row = nil
+	case "th", "td":
+		r.cell = &strings.Builder{}
+	case "a":
+		r.href = attr(t, "href")
+	case "img":
+		if alt := attr(t, "alt"); alt != "" {
+			r.text("[" + alt + "]")
+		}
+	}
+}
+
+func (r *summaryRenderer) end(tag string) {
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6", "p", "div", "details", "blockquote", "summary":
+		r.newline()
+	case "pre":
+		r.pre--
+		r.newline()
+	case "ul", "ol":
+		if len(r.lists) > 0 {
+			r.lists = r.lists[:len(r.lists)-1]
+		}
+		r.newline()
+	case "th", "td":
+		if r.cell != nil {
+			r.row = append(r.row, strings.TrimSpace(spaces.ReplaceAllString(r.cell.String(), " ")))
+			r.cell = nil
+		}
+	case "tr":
+		r.table = append(r.table, r.row)
+		r.row = nil
+	case "table":
+		r.out.WriteString(formatTable(r.table))
+		r.table = nil
+	case "a":
+		if r.href != "" {
+			r.text(" (" + r.href + ")")
+		}
+		r.href = ""
+	}
+}
+
+// formatTable aligns the columns of the rows, the first one being separated from the others as a header
+func formatTable(rows [][]string) string {
+	widths := []int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	line := func(cells []string) string {
+		padded := make([]string, len(cells))
+		for i, cell := range cells {
+			padded[i] = cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+		}
+		return strings.TrimRight(strings.Join(padded, " | "), " ") + "\n"
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		b.WriteString(line(row))
+		if i == 0 && len(rows) > 1 {
+			separator := make([]string, len(widths))
+			for j, width := range widths {
+				separator[j] = strings.Repeat("-", width)
+			}
+			b.WriteString(strings.Join(separator, "-+-") + "\n")
+		}
+	}
+	return b.String()
+}
+
+func attr(t html.Token, name string) string {
+	for _, a := range t.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
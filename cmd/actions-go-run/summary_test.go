@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderSummary(t *testing.T) {
+	summary := "<h1>Build</h1>\n" +
+		"<!-- actions-go-section:start results -->\n" +
+		"<table><tr><th>File</th><th>Errors</th></tr><tr><td>main.go</td><td>1</td></tr><tr><td>a &amp; b.go</td><td>12</td></tr></table>\n" +
+		"<!-- actions-go-section:end results -->\n" +
+		"<ul><li>one</li><li>two<ol><li>first</li><li>second</li></ol></li></ul>\n" +
+		"<details><summary>More</summary><p>hidden <a href=\"https://example.com\">details</a></p></details>\n" +
+		"## Markdown\n\n- kept as is\n"
+	assert.Equal(t, "# Build\n"+
+		"File     | Errors\n"+
+		"---------+-------\n"+
+		"main.go  | 1\n"+
+		"a & b.go | 12\n"+
+		"- one\n"+
+		"- two\n"+
+		"  1. first\n"+
+		"  2. second\n"+
+		"▸ More\n"+
+		"hidden details (https://example.com)\n"+
+		"## Markdown\n\n- kept as is", renderSummary(summary))
+	assert.Equal(t, "", renderSummary(""))
+}
@@ -0,0 +1,83 @@
+package core
+
+import "fmt"
+
+// AnnotatedError is an error carrying the annotation properties locating its cause, like a file and a line.
+// SetFailedErr reports it as an annotation at this location.
+type AnnotatedError struct {
+	Err        error
+	Properties AnnotationProperties
+}
+
+func (e *AnnotatedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AnnotatedError) Unwrap() error {
+	return e.Err
+}
+
+// Annotate wraps err with annotation properties. Returns nil when err is nil
+func Annotate(err error, properties AnnotationProperties) error {
+	if err == nil {
+		return nil
+	}
+	return &AnnotatedError{Err: err, Properties: properties}
+}
+
+// Annotatef returns a new AnnotatedError formatted like fmt.Errorf
+func Annotatef(properties AnnotationProperties, format string, args ...interface{}) error {
+	return &AnnotatedError{Err: fmt.Errorf(format, args...), Properties: properties}
+}
+
+// SetFailedErr sets the action status to failed and reports err.
+// The error chain, including errors joined with errors.Join, is walked and one error annotation
+// is issued per AnnotatedError found, with its location. The branches of the chain holding no AnnotatedError
+// are reported with their plain message, and the whole error is reported like SetFailed does
+// when the chain holds no AnnotatedError at all. Nothing is done when err is nil.
+func SetFailedErr(err error) {
+	if err == nil {
+		return
+	}
+	causes, annotated := errorCauses(err, nil)
+	if !annotated {
+		SetFailed(err.Error())
+		return
+	}
+	statusAccess.Lock()
+	status = StatusFailed
+	statusAccess.Unlock()
+	for _, cause := range causes {
+		if a, ok := cause.(*AnnotatedError); ok {
+			Error(a.Error(), a.Properties)
+		} else {
+			Error(cause.Error())
+		}
+	}
+}
+
+// errorCauses appends to found the outermost AnnotatedError of each branch of the error tree,
+// and the outermost error of the branches holding none, in order. It reports whether an AnnotatedError was found.
+func errorCauses(err error, found []error) ([]error, bool) {
+	switch e := err.(type) {
+	case *AnnotatedError:
+		return append(found, e), true
+	case interface{ Unwrap() []error }:
+		annotated := false
+		for _, wrapped := range e.Unwrap() {
+			if wrapped != nil {
+				var ok bool
+				found, ok = errorCauses(wrapped, found)
+				annotated = annotated || ok
+			}
+		}
+		return found, annotated
+	case interface{ Unwrap() error }:
+		if wrapped := e.Unwrap(); wrapped != nil {
+			if causes, ok := errorCauses(wrapped, nil); ok {
+				return append(found, causes...), true
+			}
+		}
+	}
+	return append(found, err), false
+}
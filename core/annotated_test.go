@@ -0,0 +1,70 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetStatus(t *testing.T) {
+	t.Cleanup(func() {
+		statusAccess.Lock()
+		status = StatusSuccess
+		statusAccess.Unlock()
+	})
+}
+
+func TestAnnotate(t *testing.T) {
+	assert.Nil(t, Annotate(nil, AnnotationProperties{}))
+	cause := errors.New("unexpected }")
+	err := Annotate(cause, AnnotationProperties{File: "main.go", StartLine: 3})
+	assert.EqualError(t, err, "unexpected }")
+	assert.True(t, errors.Is(err, cause))
+	annotated := &AnnotatedError{}
+	require.True(t, errors.As(fmt.Errorf("parse: %w", err), &annotated))
+	assert.Equal(t, AnnotationProperties{File: "main.go", StartLine: 3}, annotated.Properties)
+
+	assert.EqualError(t, Annotatef(AnnotationProperties{}, "line %d", 3), "line 3")
+}
+
+func TestSetFailedErr(t *testing.T) {
+	b := withInputs(t, nil)
+	resetStatus(t)
+
+	SetFailedErr(nil)
+	assert.Equal(t, StatusSuccess, status)
+	assert.Empty(t, b.String())
+
+	SetFailedErr(fmt.Errorf("build failed: %w", errors.Join(
+		Annotate(errors.New("unexpected }"), AnnotationProperties{File: "a.go", StartLine: 3, StartColumn: 7}),
+		errors.New("not annotated"),
+		fmt.Errorf("network: %w", errors.New("unreachable")),
+		fmt.Errorf("in b.go: %w", Annotate(
+			Annotate(errors.New("inner"), AnnotationProperties{File: "inner.go"}),
+			AnnotationProperties{Title: "Lint", File: "b.go", StartLine: 1},
+		)),
+	)))
+	assert.Equal(t, StatusFailed, status)
+	commands, err := ParseCommands(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Kind: "error", Properties: map[string]string{"file": "a.go", "line": "3", "col": "7"}, Message: "unexpected }"},
+		{Kind: "error", Message: "not annotated"},
+		{Kind: "error", Message: "network: unreachable"},
+		{Kind: "error", Properties: map[string]string{"title": "Lint", "file": "b.go", "line": "1"}, Message: "inner"},
+	}, commands)
+}
+
+func TestSetFailedErrWithoutAnnotation(t *testing.T) {
+	b := withInputs(t, nil)
+	resetStatus(t)
+	SetFailedErr(fmt.Errorf("wrapped: %w", errors.New("plain")))
+	assert.Equal(t, StatusFailed, status)
+	c, ok := ParseCommand(b.String())
+	require.True(t, ok)
+	assert.Equal(t, Command{Kind: "error", Message: "wrapped: plain"}, c)
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInputRequired is returned when a required input is not set or empty
+	ErrInputRequired = errors.New("input required and not supplied")
+
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// InputError describes an input that is missing or could not be parsed
+type InputError struct {
+	// Name is the name of the input, as declared in action.yml
+	Name string
+	// Err is the underlying reason
+	Err error
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("input %s: %v", e.Name, e.Err)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// BindError aggregates every input error found while binding a struct
+type BindError struct {
+	Errors []*InputError
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("invalid inputs: %s", strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual input errors
+func (e *BindError) Unwrap() []error {
+	r := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		r = append(r, err)
+	}
+	return r
+}
+
+type bindTag struct {
+	name       string
+	required   bool
+	dflt       string
+	hasDefault bool
+	mask       bool
+}
+
+func parseBindTag(field reflect.StructField) (bindTag, bool) {
+	tag, ok := field.Tag.Lookup("input")
+	if !ok || tag == "-" {
+		return bindTag{}, false
+	}
+	parts := strings.Split(tag, ",")
+	t := bindTag{name: parts[0]}
+	if t.name == "" {
+		t.name = field.Name
+	}
+	t.required = hasOption(parts[1:], "required")
+	t.dflt, t.hasDefault = field.Tag.Lookup("default")
+	if mask, ok := field.Tag.Lookup("mask"); ok {
+		t.mask, _ = strconv.ParseBool(mask)
+	}
+	return t, true
+}
+
+// BindInputs fills the struct pointed to by v with the action inputs.
+//
+// Fields are bound using the `input` struct tag, holding the input name optionally followed by
+// the ",required" option. The `default` tag provides the value to use when the input is not set or empty,
+// satisfying the required option, and fields tagged `mask:"true"` are registered as secrets with SetSecret.
+// Untagged embedded structs are bound recursively, nil embedded pointers to structs being allocated first.
+//
+// Strings, booleans (following the YAML 1.2 core schema, like GetBoolInput), base 10 integers, floats
+// and time.Duration are parsed from the input value. Slices of those types are read one item per line,
+// like GetMultilineInput. Any other type is decoded from the JSON input value.
+//
+// All missing or malformed inputs are reported at once by the returned *BindError.
+func BindInputs(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("BindInputs expects a non nil pointer to a struct, got %T", v)
+	}
+	errs := bindStruct(rv.Elem(), nil)
+	if len(errs) > 0 {
+		return &BindError{Errors: errs}
+	}
+	return nil
+}
+
+func bindStruct(v reflect.Value, errs []*InputError) []*InputError {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag, ok := parseBindTag(field)
+		if !ok {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				errs = bindStruct(v.Field(i), errs)
+			}
+			if field.Anonymous && field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+				embedded := v.Field(i)
+				if embedded.IsNil() {
+					if !embedded.CanSet() {
+						errs = append(errs, &InputError{Name: field.Name, Err: fmt.Errorf("unsupported nil embedded pointer to unexported %s", field.Type)})
+						continue
+					}
+					embedded.Set(reflect.New(field.Type.Elem()))
+				}
+				errs = bindStruct(embedded.Elem(), errs)
+			}
+			continue
+		}
+		if err := bindField(v.Field(i), tag); err != nil {
+			errs = append(errs, &InputError{Name: tag.name, Err: err})
+		}
+	}
+	return errs
+}
+
+func bindField(field reflect.Value, tag bindTag) error {
+	val, ok, err := lookupInput(tag.name)
+	if err != nil {
+		return err
+	}
+	val = strings.TrimSpace(val)
+	if !ok || val == "" {
+		if !tag.hasDefault {
+			if tag.required {
+				return ErrInputRequired
+			}
+			return nil
+		}
+		val = tag.dflt
+	}
+	if tag.mask {
+		for _, line := range strings.Split(val, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				SetSecret(line)
+			}
+		}
+	}
+	return setFieldValue(field, val)
+}
+
+func setFieldValue(field reflect.Value, val string) error {
+	if field.Kind() == reflect.Slice && isScalarKind(field.Type().Elem()) {
+		lines := splitLines(val)
+		slice := reflect.MakeSlice(field.Type(), len(lines), len(lines))
+		for i, line := range lines {
+			if err := setScalarValue(slice.Index(i), line); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	if isScalarKind(field.Type()) {
+		return setScalarValue(field, val)
+	}
+	ptr := reflect.New(field.Type())
+	if err := json.Unmarshal([]byte(val), ptr.Interface()); err != nil {
+		return fmt.Errorf("invalid JSON value: %w", err)
+	}
+	field.Set(ptr.Elem())
+	return nil
+}
+
+func isScalarKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func setScalarValue(field reflect.Value, val string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Bool:
+		b, err := parseYAMLBool(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withInputs(t *testing.T, inputs map[string]string) *bytes.Buffer {
+	t.Helper()
+	origLookup := lookupEnv
+	origInputs := jsonInputs
+	origStdout := stdout
+	b := bytes.NewBuffer(nil)
+	t.Cleanup(func() {
+		lookupEnv = origLookup
+		jsonInputs = origInputs
+		stdout = origStdout
+	})
+	stdout = b
+	jsonInputs = map[string]string{}
+	lookupEnv = func(name string) (string, bool) {
+		v, ok := inputs[name]
+		return v, ok
+	}
+	return b
+}
+
+type nestedInputs struct {
+	Owner string `json:"owner"`
+	Count int    `json:"count"`
+}
+
+type embeddedInputs struct {
+	Verbose bool `input:"verbose"`
+}
+
+type boundInputs struct {
+	embeddedInputs
+	WhoToGreet string            `input:"who-to-greet,required" default:"world"`
+	Greeting   string            `input:"greeting" default:"Hello"`
+	Token      string            `input:"token" mask:"true"`
+	Retries    int               `input:"retries" default:"3"`
+	Ratio      float64           `input:"ratio"`
+	Timeout    time.Duration     `input:"timeout" default:"1m"`
+	Files      []string          `input:"files"`
+	Ports      []uint16          `input:"ports"`
+	Nested     nestedInputs      `input:"nested"`
+	Labels     map[string]string `input:"labels"`
+	Ignored    string
+}
+
+func TestBindInputs(t *testing.T) {
+	b := withInputs(t, map[string]string{
+		"INPUT_WHO-TO-GREET": "octocat",
+		"INPUT_TOKEN":        "s3cr3t",
+		"INPUT_VERBOSE":      "True",
+		"INPUT_RATIO":        "0.5",
+		"INPUT_RETRIES":      "08",
+		"INPUT_TIMEOUT":      "",
+		"INPUT_FILES":        "a.go\n\n  b.go \n",
+		"INPUT_PORTS":        "080\n443",
+		"INPUT_NESTED":       `{"owner": "actions-go", "count": 2}`,
+		"INPUT_LABELS":       `{"a": "b"}`,
+	})
+	cfg := boundInputs{Ignored: "untouched"}
+	require.NoError(t, BindInputs(&cfg))
+	assert.Equal(t, boundInputs{
+		embeddedInputs: embeddedInputs{Verbose: true},
+		WhoToGreet:     "octocat",
+		Greeting:       "Hello",
+		Token:          "s3cr3t",
+		Retries:        8,
+		Ratio:          0.5,
+		Timeout:        time.Minute,
+		Files:          []string{"a.go", "b.go"},
+		Ports:          []uint16{80, 443},
+		Nested:         nestedInputs{Owner: "actions-go", Count: 2},
+		Labels:         map[string]string{"a": "b"},
+		Ignored:        "untouched",
+	}, cfg)
+	assert.Contains(t, b.String(), "::add-mask::s3cr3t\n")
+}
+
+func TestBindInputsJSONFallback(t *testing.T) {
+	withInputs(t, map[string]string{})
+	jsonInputs = map[string]string{"who-to-greet": "json-user", "retries": "7"}
+	cfg := boundInputs{}
+	require.NoError(t, BindInputs(&cfg))
+	assert.Equal(t, "json-user", cfg.WhoToGreet)
+	assert.Equal(t, 7, cfg.Retries)
+}
+
+func TestBindInputsErrors(t *testing.T) {
+	withInputs(t, map[string]string{
+		"INPUT_VERBOSE": "yes",
+		"INPUT_RETRIES": "three",
+		"INPUT_PORTS":   "80\n70000",
+		"INPUT_NESTED":  "{",
+	})
+	cfg := struct {
+		boundInputs
+		Required string `input:"required,required"`
+	}{}
+	err := BindInputs(&cfg)
+	require.Error(t, err)
+	bindErr := &BindError{}
+	require.True(t, errors.As(err, &bindErr))
+	names := []string{}
+	for _, e := range bindErr.Errors {
+		names = append(names, e.Name)
+	}
+	assert.Equal(t, []string{"verbose", "retries", "ports", "nested", "required"}, names)
+	assert.True(t, errors.Is(bindErr.Errors[4], ErrInputRequired))
+	assert.True(t, strings.HasPrefix(err.Error(), "invalid inputs: input verbose: "))
+}
+
+func TestBindInputsInvalidTarget(t *testing.T) {
+	assert.Error(t, BindInputs(nil))
+	assert.Error(t, BindInputs(boundInputs{}))
+	s := "string"
+	assert.Error(t, BindInputs(&s))
+}
+
+type EmbeddedPointerInputs struct {
+	Name string `input:"name,required"`
+}
+
+func TestBindInputsEmbeddedPointer(t *testing.T) {
+	withInputs(t, map[string]string{"INPUT_NAME": "octocat", "INPUT_VERBOSE": "true"})
+	cfg := struct {
+		*EmbeddedPointerInputs
+		*embeddedInputs
+	}{embeddedInputs: &embeddedInputs{}}
+	require.NoError(t, BindInputs(&cfg))
+	require.NotNil(t, cfg.EmbeddedPointerInputs)
+	assert.Equal(t, "octocat", cfg.Name)
+	assert.True(t, cfg.Verbose)
+
+	unexported := struct{ *embeddedInputs }{}
+	err := BindInputs(&unexported)
+	bindErr := &BindError{}
+	require.True(t, errors.As(err, &bindErr))
+	assert.EqualError(t, bindErr.Errors[0], "input embeddedInputs: unsupported nil embedded pointer to unexported *core.embeddedInputs")
+
+	withInputs(t, nil)
+	cfg.EmbeddedPointerInputs = nil
+	err = BindInputs(&cfg)
+	assert.True(t, errors.Is(err, ErrInputRequired))
+}
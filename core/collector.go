@@ -0,0 +1,202 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultAnnotationLevelLimit is the number of annotations of each level GitHub displays for a step
+const DefaultAnnotationLevelLimit = 10
+
+var annotationLevels = []string{"error", "warning", "notice"}
+
+// AnnotationCollectorOptions configures an AnnotationCollector
+type AnnotationCollectorOptions struct {
+	// LevelLimit is the maximum number of annotations issued for each level. Defaults to DefaultAnnotationLevelLimit
+	LevelLimit int
+	// Limit is the maximum number of annotations issued overall, errors first, then warnings and notices.
+	// Zero means no limit other than LevelLimit
+	Limit int
+	// Summary receives the annotations that could not be issued, in its "annotations-overflow" section
+	// (see Summary.Section): the content buffered in Summary is not written. Defaults to JobSummary
+	Summary *Summary
+}
+
+// AnnotationReport describes the annotations flushed by an AnnotationCollector
+type AnnotationReport struct {
+	// Issued lists the annotations issued as workflow commands
+	Issued []Annotation
+	// Overflow lists the annotations exceeding the limits, written to the job summary
+	Overflow []Annotation
+	// Duplicates is the number of identical annotations that were collected more than once
+	Duplicates int
+}
+
+// AnnotationCollector collects annotations and issues them within the limits displayed by GitHub.
+// Identical annotations are only issued once, errors are issued before warnings and notices,
+// and annotations exceeding the limits are summarised in the job summary instead of being silently dropped.
+type AnnotationCollector struct {
+	mu          sync.Mutex
+	opts        AnnotationCollectorOptions
+	annotations []Annotation
+	counts      map[Annotation]int
+	// issued counts the annotations issued by previous flushes per level, and overall
+	issued      map[string]int
+	issuedTotal int
+	// overflow accumulates the annotations exceeding the limits across flushes
+	overflow []Annotation
+}
+
+// NewAnnotationCollector returns an AnnotationCollector. A nil opts uses the default options
+func NewAnnotationCollector(opts *AnnotationCollectorOptions) *AnnotationCollector {
+	c := &AnnotationCollector{counts: map[Annotation]int{}, issued: map[string]int{}}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.LevelLimit <= 0 {
+		c.opts.LevelLimit = DefaultAnnotationLevelLimit
+	}
+	if c.opts.Summary == nil {
+		c.opts.Summary = JobSummary
+	}
+	return c
+}
+
+// Add collects an annotation
+func (c *AnnotationCollector) Add(a Annotation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts[a] == 0 {
+		c.annotations = append(c.annotations, a)
+	}
+	c.counts[a]++
+}
+
+// Error collects an error annotation
+func (c *AnnotationCollector) Error(message string, properties ...AnnotationProperties) {
+	c.Add(newAnnotation("error", message, properties))
+}
+
+// Warning collects a warning annotation
+func (c *AnnotationCollector) Warning(message string, properties ...AnnotationProperties) {
+	c.Add(newAnnotation("warning", message, properties))
+}
+
+// Notice collects a notice annotation
+func (c *AnnotationCollector) Notice(message string, properties ...AnnotationProperties) {
+	c.Add(newAnnotation("notice", message, properties))
+}
+
+func newAnnotation(level, message string, properties []AnnotationProperties) Annotation {
+	a := Annotation{Level: level, Message: message}
+	if len(properties) > 0 {
+		a.Properties = properties[0]
+	}
+	return a
+}
+
+// Flush issues the collected annotations within the limits and writes the overflow to the job summary.
+// The collector is emptied, but the limits apply to all the annotations issued by the successive flushes of the step:
+// the job summary lists the overflow of all of them.
+func (c *AnnotationCollector) Flush() (AnnotationReport, error) {
+	c.mu.Lock()
+	annotations, counts := c.annotations, c.counts
+	c.annotations, c.counts = nil, map[Annotation]int{}
+
+	report := AnnotationReport{}
+	for _, count := range counts {
+		report.Duplicates += count - 1
+	}
+	for _, level := range annotationLevels {
+		for _, a := range annotations {
+			if a.Level != level {
+				continue
+			}
+			if c.issued[level] >= c.opts.LevelLimit || (c.opts.Limit > 0 && c.issuedTotal >= c.opts.Limit) {
+				report.Overflow = append(report.Overflow, a)
+				continue
+			}
+			report.Issued = append(report.Issued, a)
+			c.issued[level]++
+			c.issuedTotal++
+		}
+	}
+	c.overflow = append(c.overflow, report.Overflow...)
+	overflow := append([]Annotation(nil), c.overflow...)
+	c.mu.Unlock()
+
+	for _, a := range report.Issued {
+		message := a.Message
+		if counts[a] > 1 {
+			message = fmt.Sprintf("%s (reported %d times)", message, counts[a])
+		}
+		IssueCommand(a.Level, annotationToProperties(a.Properties), message)
+	}
+	if len(report.Overflow) == 0 {
+		return report, nil
+	}
+	Warningf("%d annotations exceeded the display limits, see the job summary", len(report.Overflow))
+	section := c.opts.Summary.Section("annotations-overflow")
+	addOverflowSummary(section, overflow)
+	return report, section.Write()
+}
+
+func addOverflowSummary(s *Summary, overflow []Annotation) {
+	perFile := map[string]map[string]int{}
+	for _, a := range overflow {
+		file := a.Properties.File
+		if perFile[file] == nil {
+			perFile[file] = map[string]int{}
+		}
+		perFile[file][a.Level]++
+	}
+	files := make([]string, 0, len(perFile))
+	for file := range perFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	rows := [][]SummaryTableCell{{
+		{Data: "File", Header: true},
+		{Data: "Errors", Header: true},
+		{Data: "Warnings", Header: true},
+		{Data: "Notices", Header: true},
+	}}
+	for _, file := range files {
+		name := file
+		if name == "" {
+			name = "(no file)"
+		}
+		rows = append(rows, []SummaryTableCell{
+			{Data: name},
+			{Data: strconv.Itoa(perFile[file]["error"])},
+			{Data: strconv.Itoa(perFile[file]["warning"])},
+			{Data: strconv.Itoa(perFile[file]["notice"])},
+		})
+	}
+	var items strings.Builder
+	for _, a := range overflow {
+		items.WriteString(string(s.wrap("li", escapeHTML(fmt.Sprintf("%s %s: %s", a.Level, annotationLocation(a.Properties), a.Message)))))
+	}
+	s.AddHeading(fmt.Sprintf("%d annotations not displayed", len(overflow)), 3).
+		AddTable(rows).
+		AddDetailsHTML("All annotations not displayed", s.wrap("ul", HTML(items.String())))
+}
+
+// annotationLocation formats the location of an annotation like file:line:column
+func annotationLocation(p AnnotationProperties) string {
+	location := p.File
+	if location == "" {
+		location = "(no file)"
+	}
+	if p.StartLine > 0 {
+		location += ":" + strconv.Itoa(p.StartLine)
+		if p.StartColumn > 0 {
+			location += ":" + strconv.Itoa(p.StartColumn)
+		}
+	}
+	return location
+}
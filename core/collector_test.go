@@ -0,0 +1,116 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationCollector(t *testing.T) {
+	b := withInputs(t, nil)
+	name, s := withSummaryFile(t)
+	c := NewAnnotationCollector(&AnnotationCollectorOptions{LevelLimit: 2, Summary: s})
+	s.AddRaw("buffered by the caller")
+
+	c.Notice("notice", AnnotationProperties{File: "c.go"})
+	for i := 1; i <= 3; i++ {
+		c.Warning(fmt.Sprintf("warning %d", i), AnnotationProperties{File: "b.go", StartLine: i})
+	}
+	c.Error("duplicated", AnnotationProperties{File: "a.go", StartLine: 1, StartColumn: 2})
+	c.Error("duplicated", AnnotationProperties{File: "a.go", StartLine: 1, StartColumn: 2})
+	c.Error("other")
+	c.Error("overflow")
+
+	report, err := c.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Duplicates)
+	assert.Equal(t, []Annotation{
+		{Level: "error", Message: "duplicated", Properties: AnnotationProperties{File: "a.go", StartLine: 1, StartColumn: 2}},
+		{Level: "error", Message: "other"},
+		{Level: "warning", Message: "warning 1", Properties: AnnotationProperties{File: "b.go", StartLine: 1}},
+		{Level: "warning", Message: "warning 2", Properties: AnnotationProperties{File: "b.go", StartLine: 2}},
+		{Level: "notice", Message: "notice", Properties: AnnotationProperties{File: "c.go"}},
+	}, report.Issued)
+	assert.Equal(t, []Annotation{
+		{Level: "error", Message: "overflow"},
+		{Level: "warning", Message: "warning 3", Properties: AnnotationProperties{File: "b.go", StartLine: 3}},
+	}, report.Overflow)
+
+	commands, err := ParseCommands(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, commands, 6)
+	assert.Equal(t, Command{Kind: "error", Properties: map[string]string{"file": "a.go", "line": "1", "col": "2"}, Message: "duplicated (reported 2 times)"}, commands[0])
+	assert.Equal(t, Command{Kind: "warning", Message: "2 annotations exceeded the display limits, see the job summary"}, commands[5])
+
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "<h3>2 annotations not displayed</h3>")
+	assert.Contains(t, string(content), "<tr><td>(no file)</td><td>1</td><td>0</td><td>0</td></tr><tr><td>b.go</td><td>0</td><td>1</td><td>0</td></tr>")
+	assert.Contains(t, string(content), "<li>warning b.go:3: warning 3</li>")
+	assert.Contains(t, string(content), "<!-- actions-go-section:start annotations-overflow -->")
+	assert.NotContains(t, string(content), "buffered by the caller")
+	assert.Equal(t, "buffered by the caller", s.Stringify())
+
+	b.Reset()
+	report, err = c.Flush()
+	require.NoError(t, err)
+	assert.Empty(t, report.Issued)
+	assert.Empty(t, b.String())
+}
+
+func TestAnnotationCollectorTotalLimit(t *testing.T) {
+	withInputs(t, nil)
+	_, s := withSummaryFile(t)
+	c := NewAnnotationCollector(&AnnotationCollectorOptions{Limit: 2, Summary: s})
+	c.Notice("notice")
+	c.Warning("warning")
+	c.Error("error")
+	report, err := c.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, []Annotation{{Level: "error", Message: "error"}, {Level: "warning", Message: "warning"}}, report.Issued)
+	assert.Equal(t, []Annotation{{Level: "notice", Message: "notice"}}, report.Overflow)
+}
+
+func TestAnnotationCollectorDefaults(t *testing.T) {
+	c := NewAnnotationCollector(nil)
+	assert.Equal(t, DefaultAnnotationLevelLimit, c.opts.LevelLimit)
+	assert.Equal(t, JobSummary, c.opts.Summary)
+}
+
+func TestAnnotationCollectorSuccessiveFlushes(t *testing.T) {
+	b := withInputs(t, nil)
+	name, s := withSummaryFile(t)
+	c := NewAnnotationCollector(&AnnotationCollectorOptions{LevelLimit: 2, Summary: s})
+	c.Error("first 1")
+	c.Error("first 2")
+	c.Error("first 3")
+	report, err := c.Flush()
+	require.NoError(t, err)
+	assert.Len(t, report.Issued, 2)
+	assert.Equal(t, []Annotation{{Level: "error", Message: "first 3"}}, report.Overflow)
+
+	b.Reset()
+	c.Error("second 1")
+	c.Warning("second 2")
+	report, err = c.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, []Annotation{{Level: "warning", Message: "second 2"}}, report.Issued)
+	assert.Equal(t, []Annotation{{Level: "error", Message: "second 1"}}, report.Overflow)
+	commands, err := ParseCommands(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Kind: "warning", Message: "second 2"},
+		{Kind: "warning", Message: "1 annotations exceeded the display limits, see the job summary"},
+	}, commands)
+
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "<h3>2 annotations not displayed</h3>")
+	assert.Contains(t, string(content), "<li>error (no file): first 3</li><li>error (no file): second 1</li>")
+	assert.Equal(t, 1, strings.Count(string(content), "annotations not displayed</h3>"))
+}
@@ -1,7 +1,6 @@
 package core
 
 import (
-	"fmt"
 	"io"
 	"os"
 	"strings"
@@ -25,9 +24,12 @@ var (
 	}
 )
 
+// SetStdout sets the writer receiving the workflow commands and messages following the runner protocol.
+// It replaces any sink set with SetSink.
 func SetStdout(w io.Writer) {
 	stdoutSetter.Lock()
 	stdout = w
+	sink = nil
 	stdoutSetter.Unlock()
 }
 
@@ -39,36 +41,7 @@ func Issue(kind string, message ...string) {
 // IssueCommand displays a typed message with properties following github actions interface.
 // see https://github.com/actions/toolkit/blob/e69833ed16500afaa7d137a9cf6da76fb8fb54da/packages/core/src/command.ts#L19
 func IssueCommand(kind string, properties map[string]string, message string) {
-	c := &command{kind, properties, message}
-	stdoutSetter.Lock()
-	fmt.Fprintln(stdout, c.String())
-	stdoutSetter.Unlock()
-}
-
-// issueFileCommand implements stores the command in a file
-// see https://github.com/actions/toolkit/pull/571/files#diff-9ce6eb99f5fb5529e795254801e03ae56d67d3d5fcbec635f91e9a8a61ad8b64R10
-func issueFileCommandWithPerm(command string, message string, flag int, perm os.FileMode) error {
-	path, ok := os.LookupEnv(command)
-	if ok {
-		fd, err := os.OpenFile(path, flag, perm)
-		if err != nil {
-			return err
-		}
-		defer fd.Close()
-		fmt.Fprintln(fd, message)
-		return nil
-	}
-	return fmt.Errorf("unable to find command file %s", command)
-}
-
-// issueFileCommand implements stores the command in a file
-// see https://github.com/actions/toolkit/pull/571/files#diff-9ce6eb99f5fb5529e795254801e03ae56d67d3d5fcbec635f91e9a8a61ad8b64R10
-func issueFileCommand(command string, message string) error {
-	err := issueFileCommandWithPerm(command, message, os.O_APPEND|os.O_RDWR, 0)
-	if err != nil {
-		return err
-	}
-	return nil
+	Emit(Event{Kind: kind, Properties: properties, Message: message})
 }
 
 type command struct {
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// MaxOutputsSize is the maximum size, in bytes, of all outputs of a job
+const MaxOutputsSize = 1 << 20
+
+var (
+	// ErrMissingFileCommand is returned when the environment does not define the path of a file command,
+	// typically when running outside of the runner
+	ErrMissingFileCommand = errors.New("unable to find command file")
+	// ErrOutputTooLarge is returned when setting an output would exceed MaxOutputsSize
+	ErrOutputTooLarge = errors.New("outputs exceed the 1 MiB per job limit")
+
+	outputsSizeAccess sync.Mutex
+	outputsSize       int
+)
+
+// OutputsSize returns the size, in bytes, of all outputs set by this process
+func OutputsSize() int {
+	outputsSizeAccess.Lock()
+	defer outputsSizeAccess.Unlock()
+	return outputsSize
+}
+
+// writeOutputs writes the output messages, ensuring the total size of outputs stays under MaxOutputsSize
+func (c *Commands) writeOutputs(message string, size int) error {
+	outputsSizeAccess.Lock()
+	defer outputsSizeAccess.Unlock()
+	if outputsSize+size > MaxOutputsSize {
+		return fmt.Errorf("%w: %d bytes to write while %d bytes are already used", ErrOutputTooLarge, size, outputsSize)
+	}
+	if err := c.issue(GitHubOutputFilePathEnvName, message, os.O_APPEND|os.O_WRONLY, 0); err != nil {
+		return err
+	}
+	outputsSize += size
+	return nil
+}
+
+// Commands issues file commands (outputs, environment variables, path, state and step summary)
+// and reports any failure to the caller instead of falling back to the deprecated stdout commands.
+type Commands struct {
+	lookupEnv func(string) (string, bool)
+}
+
+// NewCommands returns Commands resolving the file command paths from the given environment lookup function.
+// A nil lookup uses os.LookupEnv.
+func NewCommands(lookupEnv func(string) (string, bool)) *Commands {
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	return &Commands{lookupEnv: lookupEnv}
+}
+
+// NewCommandsFromEnviron returns Commands resolving the file command paths from environ,
+// a list of "key=value" strings as returned by os.Environ
+func NewCommandsFromEnviron(environ []string) *Commands {
+	env := map[string]string{}
+	for _, kv := range environ {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+	return NewCommands(func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	})
+}
+
+// issue stores the command in the file referenced by the command environment variable
+// see https://github.com/actions/toolkit/pull/571/files#diff-9ce6eb99f5fb5529e795254801e03ae56d67d3d5fcbec635f91e9a8a61ad8b64R10
+func (c *Commands) issue(command, message string, flag int, perm os.FileMode) error {
+	path, ok := c.lookupEnv(command)
+	if !ok || path == "" {
+		return fmt.Errorf("%w %s", ErrMissingFileCommand, command)
+	}
+	fd, err := os.OpenFile(path, flag, perm)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(fd, message)
+	if closeErr := fd.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// SetOutput sets the value of an output for future steps
+func (c *Commands) SetOutput(name, value string) error {
+	if err := checkOutput(name); err != nil {
+		return err
+	}
+	message, err := formatOutput(name, value)
+	if err != nil {
+		return err
+	}
+	return c.writeOutputs(message, len(name)+len(value))
+}
+
+// ExportVariable sets the environment variable name for future steps and for the current process
+func (c *Commands) ExportVariable(name, value string) error {
+	if err := validateEnvName(name); err != nil {
+		return err
+	}
+	message, err := formatOutput(name, value)
+	if err != nil {
+		return err
+	}
+	if err := c.issue(GitHubExportEnvFilePathEnvName, message, os.O_APPEND|os.O_WRONLY, 0); err != nil {
+		return err
+	}
+	return os.Setenv(name, value)
+}
+
+// AddPath prepends path to the PATH of future steps
+func (c *Commands) AddPath(path string) error {
+	if strings.ContainsAny(path, "\r\n") {
+		return fmt.Errorf("invalid path %q: path should not contain line breaks", path)
+	}
+	return c.issue(GitHubPathFilePathEnvName, path, os.O_APPEND|os.O_WRONLY, 0)
+}
+
+// SaveState saves state for the post step of the current action
+func (c *Commands) SaveState(name, value string) error {
+	message, err := formatOutput(name, value)
+	if err != nil {
+		return err
+	}
+	return c.issue(GitHubStateFilePathEnvName, message, os.O_APPEND|os.O_WRONLY, 0)
+}
+
+// AddStepSummary appends some custom Markdown to the job summary. Registered secrets are masked
+func (c *Commands) AddStepSummary(summary string) error {
+	// os.O_CREATE: If pathname does not exist, create it as a regular file.
+	return c.issue(GitHubSummaryPathEnvName, MaskSecrets(summary), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// ReplaceStepSummary replaces all the job summary content of the current step. Registered secrets are masked
+func (c *Commands) ReplaceStepSummary(summary string) error {
+	return c.issue(GitHubSummaryPathEnvName, MaskSecrets(summary), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+}
+
+// OutputBatch accumulates outputs to write them to GITHUB_OUTPUT at once, or not at all.
+// Use Commands.Outputs to create one.
+type OutputBatch struct {
+	c        *Commands
+	messages strings.Builder
+	size     int
+	errs     []string
+}
+
+// Outputs starts a batch of outputs, written atomically by Commit
+func (c *Commands) Outputs() *OutputBatch {
+	return &OutputBatch{c: c}
+}
+
+// Set adds an output to the batch. Invalid outputs are reported by Commit
+func (b *OutputBatch) Set(name, value string) *OutputBatch {
+	if err := checkOutput(name); err != nil {
+		b.errs = append(b.errs, fmt.Sprintf("%s: %v", name, err))
+		return b
+	}
+	message, err := formatOutput(name, value)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Sprintf("%s: %v", name, err))
+		return b
+	}
+	b.messages.WriteString(message)
+	b.messages.WriteString(EOF)
+	b.size += len(name) + len(value)
+	return b
+}
+
+// Commit writes all outputs of the batch with a single write.
+// Nothing is written if any of the outputs is invalid.
+func (b *OutputBatch) Commit() error {
+	if len(b.errs) > 0 {
+		return fmt.Errorf("invalid outputs, none were written: %s", strings.Join(b.errs, "; "))
+	}
+	if b.messages.Len() == 0 {
+		return nil
+	}
+	// issue appends a line break after the message
+	message := strings.TrimSuffix(b.messages.String(), EOF)
+	if err := b.c.writeOutputs(message, b.size); err != nil {
+		return err
+	}
+	b.messages.Reset()
+	b.size = 0
+	return nil
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func commandFiles(t *testing.T) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{}
+	for _, name := range []string{GitHubOutputFilePathEnvName, GitHubExportEnvFilePathEnvName, GitHubPathFilePathEnvName, GitHubStateFilePathEnvName, GitHubSummaryPathEnvName} {
+		files[name] = filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(files[name], nil, 0644))
+	}
+	return files
+}
+
+func readCommandFile(t *testing.T, files map[string]string, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(files[name])
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestCommands(t *testing.T) {
+	files := commandFiles(t)
+	environ := []string{}
+	for k, v := range files {
+		environ = append(environ, k+"="+v)
+	}
+	c := NewCommandsFromEnviron(environ)
+	t.Setenv("MY_COMMANDS_VAR", "")
+
+	require.NoError(t, c.SetOutput("my-output", "my-value"))
+	assert.Regexp(t, regexp.MustCompile(`^my-output<<ghadelimiter_[0-9a-f-]{36}`+EOF+`my-value`+EOF), readCommandFile(t, files, GitHubOutputFilePathEnvName))
+
+	require.NoError(t, c.ExportVariable("MY_COMMANDS_VAR", "my-env"))
+	assert.Contains(t, readCommandFile(t, files, GitHubExportEnvFilePathEnvName), "MY_COMMANDS_VAR<<ghadelimiter_")
+	assert.Equal(t, "my-env", os.Getenv("MY_COMMANDS_VAR"))
+	assert.Error(t, c.ExportVariable("NODE_OPTIONS", "--inspect"))
+
+	require.NoError(t, c.AddPath("/opt/tool/bin"))
+	assert.Equal(t, "/opt/tool/bin\n", readCommandFile(t, files, GitHubPathFilePathEnvName))
+	assert.Error(t, c.AddPath("/opt\n/bin"))
+
+	require.NoError(t, c.SaveState("my-state", "state"))
+	assert.Contains(t, readCommandFile(t, files, GitHubStateFilePathEnvName), "my-state<<ghadelimiter_")
+
+	require.NoError(t, c.AddStepSummary("# summary"))
+	require.NoError(t, c.AddStepSummary("more"))
+	assert.Equal(t, "# summary\nmore\n", readCommandFile(t, files, GitHubSummaryPathEnvName))
+	require.NoError(t, c.ReplaceStepSummary("replaced"))
+	assert.Equal(t, "replaced\n", readCommandFile(t, files, GitHubSummaryPathEnvName))
+}
+
+func TestCommandsErrors(t *testing.T) {
+	c := NewCommands(func(string) (string, bool) { return "", false })
+	for _, err := range []error{
+		c.SetOutput("name", "value"),
+		c.ExportVariable("NAME", "value"),
+		c.AddPath("/bin"),
+		c.SaveState("name", "value"),
+		c.AddStepSummary("summary"),
+	} {
+		assert.True(t, errors.Is(err, ErrMissingFileCommand))
+	}
+
+	c = NewCommands(func(string) (string, bool) { return filepath.Join(t.TempDir(), "missing", "file"), true })
+	err := c.SetOutput("name", "value")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrMissingFileCommand))
+}
+
+func TestOutputBatch(t *testing.T) {
+	files := commandFiles(t)
+	c := NewCommands(func(name string) (string, bool) {
+		v, ok := files[name]
+		return v, ok
+	})
+
+	require.NoError(t, c.Outputs().Commit())
+	assert.Empty(t, readCommandFile(t, files, GitHubOutputFilePathEnvName))
+
+	err := c.Outputs().Set("first", "1").Set("invalid\nname", "2").Commit()
+	assert.ErrorContains(t, err, "invalid outputs, none were written")
+	assert.Empty(t, readCommandFile(t, files, GitHubOutputFilePathEnvName))
+
+	require.NoError(t, c.Outputs().Set("first", "1").Set("second", "2").Commit())
+	content := readCommandFile(t, files, GitHubOutputFilePathEnvName)
+	assert.Regexp(t, regexp.MustCompile(`^first<<(ghadelimiter_[0-9a-f-]{36})`+EOF+`1`+EOF+`ghadelimiter_[0-9a-f-]{36}`+EOF+EOF+`second<<`), content)
+
+	t.Cleanup(func() { SetOutputHook(nil) })
+	SetOutputHook(func(name string) error {
+		if name == "undeclared" {
+			return errors.New("undeclared output")
+		}
+		return nil
+	})
+	assert.EqualError(t, c.SetOutput("undeclared", "value"), "undeclared output")
+	assert.ErrorContains(t, c.Outputs().Set("undeclared", "value").Commit(), "undeclared: undeclared output")
+	assert.Equal(t, content, readCommandFile(t, files, GitHubOutputFilePathEnvName))
+}
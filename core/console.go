@@ -0,0 +1,212 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// ActionsGoConsoleEnvName forces the console rendering when set to true, or the runner protocol when set to false.
+	// By default, the console rendering is used when the action is not run by a runner.
+	ActionsGoConsoleEnvName = "ACTIONS_GO_CONSOLE"
+
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+var (
+	consoleOnce    sync.Once
+	defaultConsole *ConsoleSink
+)
+
+// useConsole returns whether messages written to the process standard output are rendered for humans
+func useConsole() bool {
+	switch strings.ToLower(os.Getenv(ActionsGoConsoleEnvName)) {
+	case "true", "1":
+		return true
+	case "false", "0":
+		return false
+	}
+	return os.Getenv("GITHUB_ACTIONS") != "true"
+}
+
+// consoleSink returns the console rendering the default output, when enabled
+func consoleSink() *ConsoleSink {
+	consoleOnce.Do(func() {
+		if useConsole() {
+			defaultConsole = NewConsoleSink(os.Stdout, ConsoleOptions{
+				Color: os.Getenv("NO_COLOR") == "",
+				Debug: IsDebug(),
+			})
+		}
+	})
+	return defaultConsole
+}
+
+// ConsoleOptions controls the rendering of a ConsoleSink
+type ConsoleOptions struct {
+	// Color enables ANSI colors
+	Color bool
+	// Debug shows the debug messages
+	Debug bool
+}
+
+type consoleGroup struct {
+	name  string
+	start time.Time
+}
+
+// ConsoleSink renders the workflow commands for humans, when running an action locally.
+//
+// Groups are indented and show their duration, annotations are formatted like `error: file:line:col message`,
+// debug messages are hidden unless enabled and registered secrets are masked.
+// Outputs, exported variables and paths are not printed when set, Flush prints them all in a final table.
+//
+// Unless the ACTIONS_GO_CONSOLE environment variable is false, messages written to the process standard output
+// are rendered by a ConsoleSink when the GITHUB_ACTIONS environment variable is not set.
+type ConsoleSink struct {
+	mu        sync.Mutex
+	w         io.Writer
+	opts      ConsoleOptions
+	groups    []consoleGroup
+	stopToken string
+	outputs   [][2]string
+	env       [][2]string
+	paths     []string
+}
+
+// NewConsoleSink returns a ConsoleSink writing to w
+func NewConsoleSink(w io.Writer, opts ConsoleOptions) *ConsoleSink {
+	return &ConsoleSink{w: w, opts: opts}
+}
+
+func (c *ConsoleSink) style(style, s string) string {
+	if !c.opts.Color {
+		return s
+	}
+	return style + s + ansiReset
+}
+
+func (c *ConsoleSink) println(s string) error {
+	indent := strings.Repeat("  ", len(c.groups))
+	s = indent + strings.ReplaceAll(MaskSecrets(s), "\n", "\n"+indent)
+	_, err := fmt.Fprintln(c.w, s)
+	return err
+}
+
+// Emit renders the event
+func (c *ConsoleSink) Emit(e Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopToken != "" {
+		if e.Kind == c.stopToken {
+			c.stopToken = ""
+			return nil
+		}
+		return c.println(e.String())
+	}
+	switch e.Kind {
+	case "":
+		return c.println(e.Message)
+	case "group":
+		err := c.println(c.style(ansiBold, nestedGroupMarker+" "+e.Message))
+		c.groups = append(c.groups, consoleGroup{name: e.Message, start: now()})
+		return err
+	case "endgroup":
+		if len(c.groups) == 0 {
+			return nil
+		}
+		g := c.groups[len(c.groups)-1]
+		c.groups = c.groups[:len(c.groups)-1]
+		elapsed := now().Sub(g.start).Round(time.Millisecond)
+		return c.println(c.style(ansiDim, fmt.Sprintf("%s done in %s", g.name, elapsed)))
+	case "error", "warning", "notice":
+		return c.println(c.annotation(e))
+	case "debug":
+		if !c.opts.Debug {
+			return nil
+		}
+		return c.println(c.style(ansiDim, "debug: "+e.Message))
+	case "add-mask", "echo", "add-matcher", "remove-matcher":
+		return nil
+	case "stop-commands":
+		c.stopToken = e.Message
+		return nil
+	case "set-output":
+		c.outputs = append(c.outputs, [2]string{e.Properties["name"], e.Message})
+		return nil
+	case "set-env":
+		c.env = append(c.env, [2]string{e.Properties["name"], e.Message})
+		return nil
+	case "add-path":
+		c.paths = append(c.paths, e.Message)
+		return nil
+	case "save-state":
+		return nil
+	}
+	return c.println(c.style(ansiDim, e.String()))
+}
+
+func (c *ConsoleSink) annotation(e Event) string {
+	color := map[string]string{"error": ansiRed, "warning": ansiYellow, "notice": ansiCyan}[e.Kind]
+	a, _ := e.Annotation()
+	message := e.Message
+	if a.Properties.Title != "" {
+		message = a.Properties.Title + ": " + message
+	}
+	if a.Properties.File != "" {
+		message = annotationLocation(a.Properties) + " " + message
+	}
+	return c.style(ansiBold+color, e.Kind+":") + " " + message
+}
+
+// Flush ends the groups left open and prints the outputs, exported variables and paths set so far
+func (c *ConsoleSink) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.groups = nil
+	if len(c.outputs) == 0 && len(c.env) == 0 && len(c.paths) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(c.w, 0, 4, 2, ' ', 0)
+	section := func(title string, rows [][2]string) {
+		if len(rows) == 0 {
+			return
+		}
+		fmt.Fprintln(tw, c.style(ansiBold, title))
+		for _, row := range rows {
+			value := strings.NewReplacer("\r", `\r`, "\n", `\n`).Replace(row[1])
+			fmt.Fprintf(tw, "  %s\t%s\n", row[0], MaskSecrets(value))
+		}
+	}
+	paths := make([][2]string, 0, len(c.paths))
+	for _, p := range c.paths {
+		paths = append(paths, [2]string{"PATH", p})
+	}
+	section("Outputs", c.outputs)
+	section("Environment", c.env)
+	section("Paths", paths)
+	c.outputs, c.env, c.paths = nil, nil, nil
+	return tw.Flush()
+}
+
+// Flush flushes the current sink when it buffers messages, like ConsoleSink printing its final table.
+// action.Run calls Flush before exiting.
+func Flush() error {
+	stdoutSetter.Lock()
+	s := currentSinkLocked()
+	stdoutSetter.Unlock()
+	if f, ok := s.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
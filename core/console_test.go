@@ -0,0 +1,85 @@
+package core
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	withSecrets(t)
+	withClock(t, 1500*time.Millisecond)
+	b := &bytes.Buffer{}
+	console := NewConsoleSink(b, ConsoleOptions{})
+	withSink(t, console)
+
+	SetSecret("s3cr3t")
+	Group("build", func() {
+		Info("token is s3cr3t\nnext line")
+		Debug("hidden")
+		Error("unexpected }", AnnotationProperties{File: "main.go", StartLine: 3, StartColumn: 5})
+		Group("nested", func() {
+			Warning("careful", AnnotationProperties{Title: "lint"})
+		})
+	})
+	WithoutCommands("token", func() {
+		Notice("not interpreted")
+	})
+	Notice("done")
+	IssueCommand("set-output", map[string]string{"name": "greeting"}, "Hello\ns3cr3t")
+	IssueCommand("set-env", map[string]string{"name": "GREETED"}, "octocat")
+	IssueCommand("add-path", nil, "/opt/bin")
+	IssueCommand("custom", nil, "raw")
+	require.NoError(t, Flush())
+
+	assert.Equal(t, `▸ build
+  token is ***
+  next line
+  error: main.go:3:5 unexpected }
+  ▸ nested
+    warning: lint: careful
+  nested done in 1.5s
+build done in 4.5s
+::notice::not interpreted
+notice: done
+::custom::raw
+Outputs
+  greeting  Hello\n***
+Environment
+  GREETED  octocat
+Paths
+  PATH  /opt/bin
+`, b.String())
+
+	b.Reset()
+	require.NoError(t, console.Flush())
+	assert.Empty(t, b.String())
+}
+
+func TestConsoleSinkOptions(t *testing.T) {
+	b := &bytes.Buffer{}
+	console := NewConsoleSink(b, ConsoleOptions{Color: true, Debug: true})
+	console.Emit(Event{Kind: "debug", Message: "shown"})
+	console.Emit(Event{Kind: "error", Message: "failed"})
+	assert.Equal(t, "\x1b[2mdebug: shown\x1b[0m\n\x1b[1m\x1b[31merror:\x1b[0m failed\n", b.String())
+}
+
+func TestUseConsole(t *testing.T) {
+	t.Setenv(ActionsGoConsoleEnvName, "")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	assert.False(t, useConsole())
+	t.Setenv("GITHUB_ACTIONS", "")
+	assert.True(t, useConsole())
+	t.Setenv(ActionsGoConsoleEnvName, "false")
+	assert.False(t, useConsole())
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv(ActionsGoConsoleEnvName, "true")
+	assert.True(t, useConsole())
+}
@@ -1,16 +1,19 @@
 package core
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/google/uuid"
 )
 
 const (
-	delimiter = "_GitHubActionsGoFileCommandDelimeter_"
+	delimiterPrefix = "ghadelimiter_"
 
 	// StatusFailed is returned by Status() in case this action has been marked as failed
 	StatusFailed = 1
@@ -43,8 +46,17 @@ type AnnotationProperties struct {
 	EndColumn int
 }
 
+// Annotation is an error, warning or notice annotation, as issued by Error, Warning and Notice
+type Annotation struct {
+	// Level is the kind of annotation: "error", "warning" or "notice"
+	Level      string
+	Message    string
+	Properties AnnotationProperties
+}
+
 var (
 	status       = StatusSuccess
+	fileCommands = NewCommands(os.LookupEnv)
 	statusAccess = &sync.Mutex{}
 	lookupEnv    = os.LookupEnv
 	open         = func(path string, flag int, perm os.FileMode) (File, error) {
@@ -55,28 +67,13 @@ var (
 		return fd, nil
 	}
 	jsonInputs = func() map[string]string {
-		r := map[string]string{}
 		encoded, ok := os.LookupEnv(ActionsGoJsonInputEnvName)
-		if ok {
-			raw := map[string]interface{}{}
-			err := json.Unmarshal([]byte(encoded), &raw)
-			if err != nil {
-				Warningf("Unable to decode action-go inputs: %v", err)
-				return r
-			}
-			for k, v := range raw {
-				switch s := v.(type) {
-				case string:
-					r[k] = s
-				default:
-					data, err := json.Marshal(v)
-					if err != nil {
-						Debugf("unable to serialise %s input: %v", k, err)
-						continue
-					}
-					r[k] = string(data)
-				}
-			}
+		if !ok {
+			return map[string]string{}
+		}
+		r, err := parseJSONInputs([]byte(encoded))
+		if err != nil {
+			Warningf("Unable to decode action-go inputs: %v", err)
 		}
 		return r
 	}()
@@ -88,6 +85,21 @@ type File interface {
 	io.Closer
 }
 
+func propertiesToAnnotation(props map[string]string) AnnotationProperties {
+	atoi := func(name string) int {
+		i, _ := strconv.Atoi(props[name])
+		return i
+	}
+	return AnnotationProperties{
+		Title:       props["title"],
+		File:        props["file"],
+		StartLine:   atoi("line"),
+		EndLine:     atoi("endLine"),
+		StartColumn: atoi("col"),
+		EndColumn:   atoi("endColumn"),
+	}
+}
+
 func annotationToProperties(p AnnotationProperties) map[string]string {
 	props := map[string]string{}
 	if p.Title != "" {
@@ -111,7 +123,19 @@ func annotationToProperties(p AnnotationProperties) map[string]string {
 	return props
 }
 
-func formatOutput(name, value string) string {
+// formatOutput formats a key value file command using a random heredoc delimiter,
+// so that neither the name nor the value can inject other commands.
+func formatOutput(name, value string) (string, error) {
+	delimiter := delimiterPrefix + uuid.New().String()
+	if strings.ContainsAny(name, "\r\n") {
+		return "", fmt.Errorf("unexpected input: name should not contain line breaks")
+	}
+	if strings.Contains(name, delimiter) {
+		return "", fmt.Errorf("unexpected input: name should not contain the delimiter %q", delimiter)
+	}
+	if strings.Contains(value, delimiter) {
+		return "", fmt.Errorf("unexpected input: value should not contain the delimiter %q", delimiter)
+	}
 	return strings.Join(
 		[]string{
 			fmt.Sprintf("%s<<%s", name, delimiter),
@@ -120,47 +144,100 @@ func formatOutput(name, value string) string {
 			"",
 		},
 		EOF,
-	)
+	), nil
 }
 
-// ExportVariable sets the environment varaible name (for this action and future actions)
+var (
+	envAccess = sync.Mutex{}
+	// protectedEnvVariables lists variables altering the behaviour of the runner or of future steps
+	protectedEnvVariables = defaultProtectedEnvVariables()
+)
+
+func defaultProtectedEnvVariables() map[string]bool {
+	return map[string]bool{
+		"NODE_OPTIONS":          true,
+		"LD_PRELOAD":            true,
+		"LD_LIBRARY_PATH":       true,
+		"DYLD_INSERT_LIBRARIES": true,
+		"DYLD_LIBRARY_PATH":     true,
+		"BASH_ENV":              true,
+		"ENV":                   true,
+		"PROMPT_COMMAND":        true,
+		"PATH":                  true,
+	}
+}
+
+// AllowEnvVariable allows ExportVariable to export protected variables such as NODE_OPTIONS or LD_PRELOAD.
+// Only allow them when the exported value is trusted.
+func AllowEnvVariable(names ...string) {
+	envAccess.Lock()
+	defer envAccess.Unlock()
+	for _, name := range names {
+		protectedEnvVariables[strings.ToUpper(name)] = false
+	}
+}
+
+func validateEnvName(name string) error {
+	if name == "" {
+		return fmt.Errorf("invalid environment variable name: name should not be empty")
+	}
+	if strings.ContainsAny(name, "=\r\n\x00") {
+		return fmt.Errorf("invalid environment variable name %q: name should not contain '=', line breaks or null characters", name)
+	}
+	envAccess.Lock()
+	defer envAccess.Unlock()
+	if protectedEnvVariables[strings.ToUpper(name)] {
+		return fmt.Errorf("refusing to export protected environment variable %s, use AllowEnvVariable to allow it", name)
+	}
+	return nil
+}
+
+// ExportVariable sets the environment varaible name (for this action and future actions).
+// Names containing '=' or line breaks, as well as protected names like NODE_OPTIONS, are rejected
+// unless allowed with AllowEnvVariable.
+// The action is marked as failed when the variable is rejected or can't be written.
+// Use Commands.ExportVariable to handle errors.
 func ExportVariable(name, value string) {
-	if err := issueFileCommand(GitHubExportEnvFilePathEnvName, formatOutput(name, value)); err != nil {
+	err := fileCommands.ExportVariable(name, value)
+	if errors.Is(err, ErrMissingFileCommand) {
 		IssueCommand("set-env", map[string]string{"name": name}, value)
+		os.Setenv(name, value)
+	} else if err != nil {
+		SetFailedf("unable to export variable %s: %v", name, err)
 	}
-	os.Setenv(name, value)
 }
 
-// SetSecret registers a secret which will get masked from logs
+// SetSecret registers a secret which will get masked from logs.
+// The secret is also masked by MaskSecrets and MaskingWriter, for example when running outside of the runner.
 func SetSecret(secret string) {
 	Issue("add-mask", secret)
+	// registered after issuing the command, so that a masked stdout still sends the secret to the runner
+	secrets.add(secret)
 }
 
 // AddPath prepends inputPath to the PATH (for this action and future actions)
+// The action is marked as failed when the path can't be written.
+// Use Commands.AddPath to handle errors.
 func AddPath(path string) {
-	if err := issueFileCommand(GitHubPathFilePathEnvName, path); err != nil {
+	err := fileCommands.AddPath(path)
+	if errors.Is(err, ErrMissingFileCommand) {
 		Issue("add-path", path)
+	} else if err != nil {
+		SetFailedf("unable to add path %s: %v", path, err)
 	}
 	// TODO js: process.env['PATH'] = `${inputPath}${path.delimiter}${process.env['PATH']}`
 }
 
 // GetBoolInput gets the value of an input and returns whether it is a truthy value per
 // the YAML 1.2 "core schema" specification: true | True | TRUE | false | False | FALSE.
-// Returns false if the input is not set. Returns an error if the value is set but is not
-// a valid boolean per the YAML spec.
+// Returns false if the input is not set or is not a valid boolean per the YAML spec.
+// Use GetBoolInputE to get an error when the value is invalid.
 func GetBoolInput(name string) bool {
-	val, ok := GetInput(name)
-	if !ok {
-		return false
-	}
-	switch val {
-	case "true", "True", "TRUE":
-		return true
-	case "false", "False", "FALSE":
-		return false
-	default:
+	b, err := GetBoolInputE(name)
+	if err != nil {
 		return false
 	}
+	return b
 }
 
 // GetMultilineInput gets the values of a multiline input. Each value is trimmed.
@@ -170,29 +247,26 @@ func GetMultilineInput(name string) []string {
 	if !ok {
 		return []string{}
 	}
-	lines := strings.Split(val, "\n")
-	result := make([]string, 0, len(lines))
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-	return result
+	return splitLines(val)
 }
 
 // GetInput gets the value of an input.  The value is also trimmed.
+// An input rejected by the input hook, like a required input missing per the action metadata (see metadata.Install),
+// marks the action as failed. Use GetInputE to handle the error instead.
 func GetInput(name string) (string, bool) {
-	val, ok := lookupEnv(strings.ToUpper("INPUT_" + strings.Replace(name, " ", "_", -1)))
-	if !ok {
-		Debug("Did not find the input using plain gha input format, trying the actions-go one")
-		val, ok := jsonInputs[name]
-		if ok {
-			return strings.TrimSpace(val), true
-		}
-		return "", false
+	val, ok, err := lookupInput(name)
+	if err != nil {
+		SetFailed(wrapInputError(name, err).Error())
+	}
+	return strings.TrimSpace(val), ok
+}
+
+func lookupInput(name string) (string, bool, error) {
+	val, ok := currentInputProvider().LookupInput(name)
+	if hook := getInputHook(); hook != nil {
+		return hook(name, val, ok)
 	}
-	return strings.TrimSpace(val), true
+	return val, ok, nil
 }
 
 // GetInputOrDefault gets the value of an input. If value is not found, a default value is used
@@ -204,11 +278,16 @@ func GetInputOrDefault(name, dflt string) string {
 	return dflt
 }
 
-// SetOutput sets the value of an output for future actions
+// SetOutput sets the value of an output for future actions.
+// The action is marked as failed when the output can't be written.
+// Use Commands.SetOutput to handle errors.
 func SetOutput(name, value string) {
-	if err := issueFileCommand(GitHubOutputFilePathEnvName, formatOutput(name, value)); err != nil {
+	err := fileCommands.SetOutput(name, value)
+	if errors.Is(err, ErrMissingFileCommand) {
 		Warningf("did not find output file from environment variable %s, falling back to the deprecated command implementation", GitHubOutputFilePathEnvName)
 		IssueCommand("set-output", map[string]string{"name": name}, value)
+	} else if err != nil {
+		SetFailedf("unable to set output %s: %v", name, err)
 	}
 }
 
@@ -222,6 +301,29 @@ func SetCommandEcho(enabled bool) {
 	Issue("echo", val)
 }
 
+// Status returns StatusFailed once the action has been marked as failed, StatusSuccess otherwise
+func Status() int {
+	statusAccess.Lock()
+	defer statusAccess.Unlock()
+	return status
+}
+
+// ResetState restores the action status to StatusSuccess, the outputs size to 0, forgets the registered secrets
+// and protects again the environment variables allowed with AllowEnvVariable.
+// It is meant for test harnesses running several actions in the same process, like coretest.
+func ResetState() {
+	statusAccess.Lock()
+	status = StatusSuccess
+	statusAccess.Unlock()
+	outputsSizeAccess.Lock()
+	outputsSize = 0
+	outputsSizeAccess.Unlock()
+	secrets.reset()
+	envAccess.Lock()
+	protectedEnvVariables = defaultProtectedEnvVariables()
+	envAccess.Unlock()
+}
+
 // SetFailedf sets the action status to failed and sets an error message
 func SetFailedf(format string, args ...interface{}) {
 	SetFailed(fmt.Sprintf(format, args...))
@@ -289,7 +391,7 @@ func Noticef(format string, args ...interface{}) {
 
 // Info writes the message on the console
 func Info(message string) {
-	fmt.Println(message)
+	Emit(Event{Message: message})
 }
 
 // Infof writes debug message to user log
@@ -340,10 +442,15 @@ func WithoutCommands(endToken string, f func()) {
 }
 
 // SaveState saves state for current action, the state can only be retrieved by this action's post job execution.
+// The action is marked as failed when the state can't be written.
+// Use Commands.SaveState to handle errors.
 func SaveState(name, value string) {
-	if err := issueFileCommand(GitHubStateFilePathEnvName, formatOutput(name, value)); err != nil {
+	err := fileCommands.SaveState(name, value)
+	if errors.Is(err, ErrMissingFileCommand) {
 		Warningf("did not find state file from environment variable %s, falling back to the deprecated command implementation", GitHubStateFilePathEnvName)
 		IssueCommand("save-state", map[string]string{"name": name}, value)
+	} else if err != nil {
+		SetFailedf("unable to save state %s: %v", name, err)
 	}
 }
 
@@ -362,8 +469,7 @@ func IsDebug() bool {
 // result of a workflow run doesn't need to go into the logs to see important information related to the run, such as failures.
 // see: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#adding-a-job-summary
 func AddStepSummary(summary string) {
-	// os.O_CREATE: If pathname does not exist, create it as a regular file.
-	if err := issueFileCommandWithPerm(GitHubSummaryPathEnvName, summary, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644); err != nil {
+	if err := fileCommands.AddStepSummary(summary); err != nil {
 		Warningf("failed to add step summary: %v", err)
 	}
 }
@@ -371,8 +477,7 @@ func AddStepSummary(summary string) {
 // ReplaceStepSummary clear all content for the current step
 // see: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#overwriting-job-summaries
 func ReplaceStepSummary(summary string) {
-	// os.O_CREATE: If pathname does not exist, create it as a regular file.
-	if err := issueFileCommandWithPerm(GitHubSummaryPathEnvName, summary, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0); err != nil {
+	if err := fileCommands.ReplaceStepSummary(summary); err != nil {
 		Warningf("failed to replace step summary: %v", err)
 	}
 }
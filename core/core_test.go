@@ -3,7 +3,10 @@ package core
 import (
 	"bytes"
 	"os"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -67,7 +70,75 @@ func TestFormatOutput(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("This test only runs on unix with \\n line separator")
 	}
-	assert.Equal(t, "my-name<<_GitHubActionsGoFileCommandDelimeter_\nmy-value\n_GitHubActionsGoFileCommandDelimeter_\n", formatOutput("my-name", "my-value"))
+	out, err := formatOutput("my-name", "my-value")
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^my-name<<(ghadelimiter_[0-9a-f-]{36})\nmy-value\n(ghadelimiter_[0-9a-f-]{36})\n$`), out)
+	lines := strings.Split(out, "\n")
+	assert.Equal(t, "my-name<<"+lines[2], lines[0])
+
+	other, err := formatOutput("my-name", "my-value")
+	require.NoError(t, err)
+	assert.NotEqual(t, out, other, "delimiters must be random for each write")
+
+	_, err = formatOutput("my\nname", "my-value")
+	assert.Error(t, err)
+}
+
+func TestExportVariableValidation(t *testing.T) {
+	b := withInputs(t, nil)
+	env := filepath.Join(t.TempDir(), "env")
+	require.NoError(t, os.WriteFile(env, nil, 0644))
+	t.Setenv(GitHubExportEnvFilePathEnvName, env)
+	t.Setenv("NODE_OPTIONS", "")
+	t.Cleanup(func() {
+		envAccess.Lock()
+		protectedEnvVariables["NODE_OPTIONS"] = true
+		envAccess.Unlock()
+	})
+
+	for _, name := range []string{"", "A=B", "A\nB", "NODE_OPTIONS", "node_options", "LD_PRELOAD"} {
+		t.Run(name, func(t *testing.T) {
+			b.Reset()
+			resetStatus(t)
+			ExportVariable(name, "value")
+			assert.Contains(t, b.String(), "::error::unable to export variable")
+			assert.Equal(t, StatusFailed, Status())
+		})
+	}
+	content, err := os.ReadFile(env)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+	assert.Equal(t, "", os.Getenv("NODE_OPTIONS"))
+
+	AllowEnvVariable("node_options")
+	ExportVariable("NODE_OPTIONS", "--max-old-space-size=4096")
+	content, err = os.ReadFile(env)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "NODE_OPTIONS<<ghadelimiter_")
+	assert.Equal(t, "--max-old-space-size=4096", os.Getenv("NODE_OPTIONS"))
+}
+
+func TestFileCommandFailures(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(GitHubExportEnvFilePathEnvName, dir)
+	t.Setenv(GitHubPathFilePathEnvName, dir)
+	t.Setenv(GitHubStateFilePathEnvName, dir)
+	t.Setenv(GitHubOutputFilePathEnvName, dir)
+
+	for name, write := range map[string]func(){
+		"unable to export variable": func() { ExportVariable("name", "value") },
+		"unable to add path":        func() { AddPath("/bin") },
+		"unable to save state":      func() { SaveState("name", "value") },
+		"unable to set output":      func() { SetOutput("name", "value") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := withInputs(t, nil)
+			resetStatus(t)
+			write()
+			assert.Contains(t, b.String(), "::error::"+name)
+			assert.Equal(t, StatusFailed, Status())
+		})
+	}
 }
 
 func TestOutputTasks(t *testing.T) {
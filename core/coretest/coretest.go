@@ -0,0 +1,234 @@
+// Package coretest runs actions built with the core package in a sandbox, for testing purpose.
+//
+//	func TestAction(t *testing.T) {
+//		a := coretest.New(t, map[string]string{"who-to-greet": "octocat"})
+//		run()
+//		assert.Equal(t, map[string]string{"greeting": "Hello octocat"}, a.Outputs())
+//		assert.False(t, a.Failed())
+//	}
+package coretest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/actions-go/toolkit/core"
+)
+
+// Group is an output group written by the action, see core.StartGroup
+type Group struct {
+	Name string
+	// Events holds the messages written in the group
+	Events []core.Event
+}
+
+// Action is a sandbox capturing everything an action reports to the runner
+type Action struct {
+	t        testing.TB
+	files    map[string]string
+	dirs     map[string]string
+	recorder *core.Recorder
+}
+
+// New sandboxes the action for the duration of the test t.
+//
+// It creates empty GITHUB_OUTPUT, GITHUB_ENV, GITHUB_STATE, GITHUB_PATH and GITHUB_STEP_SUMMARY files
+// and RUNNER_TEMP and RUNNER_TOOL_CACHE directories, sets the inputs, records all messages written by the core package
+// and resets the action status, outputs size and secrets. Everything is restored when the test completes,
+// including the environment variables exported by the action, the input and output hooks (see metadata.Install),
+// the variables allowed with core.AllowEnvVariable and the shutdown timeout.
+// Inputs are only read from the INPUT_<NAME> environment variables set by New and SetInput.
+//
+// Packages reading the environment at initialization, like the cache package, are not affected by the sandbox.
+// As it relies on t.Setenv, New cannot be used in parallel tests.
+func New(t testing.TB, inputs ...map[string]string) *Action {
+	t.Helper()
+	environ := os.Environ()
+	root := t.TempDir()
+	a := &Action{
+		t:        t,
+		files:    map[string]string{},
+		dirs:     map[string]string{},
+		recorder: core.NewRecorder(),
+	}
+	for _, name := range []string{
+		core.GitHubOutputFilePathEnvName,
+		core.GitHubExportEnvFilePathEnvName,
+		core.GitHubStateFilePathEnvName,
+		core.GitHubPathFilePathEnvName,
+		core.GitHubSummaryPathEnvName,
+	} {
+		path := filepath.Join(root, strings.ToLower(name))
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatalf("unable to create %s file: %v", name, err)
+		}
+		a.files[name] = path
+		t.Setenv(name, path)
+	}
+	for _, name := range []string{"RUNNER_TEMP", "RUNNER_TOOL_CACHE"} {
+		path := filepath.Join(root, strings.ToLower(name))
+		if err := os.Mkdir(path, 0700); err != nil {
+			t.Fatalf("unable to create %s directory: %v", name, err)
+		}
+		a.dirs[name] = path
+		t.Setenv(name, path)
+	}
+	previous := core.SwapSink(a.recorder)
+	core.SetInputProviders(core.EnvInputs())
+	core.ResetState()
+	t.Cleanup(func() {
+		core.SwapSink(previous)
+		core.SetInputProviders()
+		core.SetInputHook(nil)
+		core.SetOutputHook(nil)
+		core.SetShutdownTimeout(core.DefaultShutdownTimeout)
+		core.ResetState()
+		restoreEnv(environ)
+	})
+	for _, in := range inputs {
+		for name, value := range in {
+			a.SetInput(name, value)
+		}
+	}
+	return a
+}
+
+// restoreEnv restores the environment to environ, undoing the variables exported by the action
+func restoreEnv(environ []string) {
+	values := map[string]string{}
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			values[name] = value
+		}
+	}
+	for _, kv := range os.Environ() {
+		if name, _, ok := strings.Cut(kv, "="); ok {
+			if _, kept := values[name]; !kept {
+				os.Unsetenv(name)
+			}
+		}
+	}
+	for name, value := range values {
+		if os.Getenv(name) != value {
+			os.Setenv(name, value)
+		}
+	}
+}
+
+// SetInput sets the value of an action input
+func (a *Action) SetInput(name, value string) {
+	a.t.Setenv(strings.ToUpper("INPUT_"+strings.Replace(name, " ", "_", -1)), value)
+}
+
+// SetState sets the value of a state, as saved by core.SaveState in a previous phase of the action
+func (a *Action) SetState(name, value string) {
+	a.t.Setenv("STATE_"+name, value)
+}
+
+// TempDir returns the RUNNER_TEMP directory
+func (a *Action) TempDir() string {
+	return a.dirs["RUNNER_TEMP"]
+}
+
+// ToolCacheDir returns the RUNNER_TOOL_CACHE directory
+func (a *Action) ToolCacheDir() string {
+	return a.dirs["RUNNER_TOOL_CACHE"]
+}
+
+// Events returns all the messages written by the action
+func (a *Action) Events() []core.Event {
+	return a.recorder.Events()
+}
+
+// Stdout returns the messages written by the action, as the runner would read them
+func (a *Action) Stdout() string {
+	return a.recorder.String()
+}
+
+// Outputs returns the outputs set by the action, including the ones set with the deprecated set-output command
+func (a *Action) Outputs() map[string]string {
+	return a.fileCommand(core.GitHubOutputFilePathEnvName, "set-output")
+}
+
+// ExportedEnv returns the environment variables exported by the action,
+// including the ones exported with the deprecated set-env command
+func (a *Action) ExportedEnv() map[string]string {
+	return a.fileCommand(core.GitHubExportEnvFilePathEnvName, "set-env")
+}
+
+// State returns the state saved by the action, including the one saved with the deprecated save-state command
+func (a *Action) State() map[string]string {
+	return a.fileCommand(core.GitHubStateFilePathEnvName, "save-state")
+}
+
+// Paths returns the directories added to the PATH by the action,
+// including the ones added with the deprecated add-path command
+func (a *Action) Paths() []string {
+	a.t.Helper()
+	paths, err := core.ReadPathFileCommand(a.files[core.GitHubPathFilePathEnvName])
+	if err != nil {
+		a.t.Fatalf("unable to read %s: %v", core.GitHubPathFilePathEnvName, err)
+	}
+	for _, e := range a.recorder.Commands("add-path") {
+		paths = append(paths, e.Message)
+	}
+	return paths
+}
+
+// Annotations returns the errors, warnings and notices issued by the action
+func (a *Action) Annotations() []core.Annotation {
+	annotations := []core.Annotation{}
+	for _, e := range a.recorder.Events() {
+		if annotation, ok := e.Annotation(); ok {
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations
+}
+
+// Groups returns the output groups written by the action, in order
+func (a *Action) Groups() []Group {
+	groups := []Group{}
+	var current *Group
+	for _, e := range a.recorder.Events() {
+		switch {
+		case e.Kind == "group":
+			groups = append(groups, Group{Name: e.Message, Events: []core.Event{}})
+			current = &groups[len(groups)-1]
+		case e.Kind == "endgroup":
+			current = nil
+		case current != nil:
+			current.Events = append(current.Events, e)
+		}
+	}
+	return groups
+}
+
+// Summary returns the content of the job summary written by the action
+func (a *Action) Summary() string {
+	a.t.Helper()
+	b, err := os.ReadFile(a.files[core.GitHubSummaryPathEnvName])
+	if err != nil {
+		a.t.Fatalf("unable to read %s: %v", core.GitHubSummaryPathEnvName, err)
+	}
+	return string(b)
+}
+
+// Failed returns whether the action has been marked as failed, see core.SetFailed
+func (a *Action) Failed() bool {
+	return core.Status() == core.StatusFailed
+}
+
+func (a *Action) fileCommand(name, deprecated string) map[string]string {
+	a.t.Helper()
+	values, err := core.ReadFileCommand(a.files[name])
+	if err != nil {
+		a.t.Fatalf("unable to read %s: %v", name, err)
+	}
+	for _, e := range a.recorder.Commands(deprecated) {
+		values[e.Properties["name"]] = e.Message
+	}
+	return values
+}
@@ -0,0 +1,75 @@
+package coretest
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/actions-go/toolkit/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAction(t *testing.T) {
+	var a *Action
+	t.Run("sandboxed", func(t *testing.T) {
+		a = New(t, map[string]string{"who to greet": "octocat"})
+		a.SetState("pid", "42")
+		core.SetInputHook(func(name, value string, found bool) (string, bool, error) { return "hooked", true, nil })
+		core.SetShutdownTimeout(time.Minute)
+		core.AllowEnvVariable("LD_PRELOAD")
+
+		who, ok := core.GetInput("who to greet")
+		assert.True(t, ok)
+		assert.Equal(t, "hooked", who)
+		core.SetInputHook(nil)
+		assert.Equal(t, "42", core.GetState("pid"))
+		assert.DirExists(t, a.TempDir())
+		assert.DirExists(t, a.ToolCacheDir())
+		assert.Equal(t, a.TempDir(), os.Getenv("RUNNER_TEMP"))
+
+		core.SetOutput("greeting", "Hello\noctocat")
+		core.ExportVariable("GREETED", "octocat")
+		core.AddPath("/opt/bin")
+		core.SaveState("done", "true")
+		core.Group("greet", func() {
+			core.Info("Hello octocat")
+			core.Warning("be nice", core.AnnotationProperties{File: "main.go", StartLine: 3})
+		})
+		core.Notice("greeted")
+		core.AddStepSummary("# Greetings")
+		core.SetFailedErr(errors.New("boom"))
+
+		assert.Equal(t, map[string]string{"greeting": "Hello\noctocat"}, a.Outputs())
+		assert.Equal(t, map[string]string{"GREETED": "octocat"}, a.ExportedEnv())
+		assert.Equal(t, map[string]string{"done": "true"}, a.State())
+		assert.Equal(t, []string{"/opt/bin"}, a.Paths())
+		assert.Equal(t, []core.Annotation{
+			{Level: "warning", Message: "be nice", Properties: core.AnnotationProperties{File: "main.go", StartLine: 3}},
+			{Level: "notice", Message: "greeted"},
+			{Level: "error", Message: "boom"},
+		}, a.Annotations())
+		assert.Equal(t, []Group{{Name: "greet", Events: []core.Event{
+			{Message: "Hello octocat"},
+			{Kind: "warning", Properties: map[string]string{"file": "main.go", "line": "3"}, Message: "be nice"},
+		}}}, a.Groups())
+		assert.Contains(t, a.Summary(), "# Greetings")
+		assert.Contains(t, a.Stdout(), "::notice::greeted\n")
+		assert.NotEmpty(t, a.Events())
+		assert.True(t, a.Failed())
+	})
+	assert.Equal(t, core.StatusSuccess, core.Status())
+	_, ok := os.LookupEnv("INPUT_WHO_TO_GREET")
+	assert.False(t, ok)
+	_, ok = os.LookupEnv("GREETED")
+	assert.False(t, ok)
+	assert.Nil(t, core.SwapSink(nil))
+
+	t.Run("restored", func(t *testing.T) {
+		New(t, map[string]string{"who to greet": "octocat"})
+		who, _ := core.GetInput("who to greet")
+		assert.Equal(t, "octocat", who)
+		assert.Error(t, core.NewCommands(nil).ExportVariable("LD_PRELOAD", "evil.so"))
+	})
+	assert.NotEqual(t, a.files[core.GitHubOutputFilePathEnvName], os.Getenv(core.GitHubOutputFilePathEnvName))
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// GroupSuccessMarker prefixes the status line of groups completing successfully
+	GroupSuccessMarker = "✓"
+	// GroupFailureMarker prefixes the status line, or the title, of groups returning an error or panicking
+	GroupFailureMarker = "✗"
+	// nestedGroupMarker prefixes the title of emulated nested groups
+	nestedGroupMarker = "▸"
+)
+
+var (
+	now = time.Now
+	// indent prefixes plain log lines written by Info in emulated nested groups, guarded by stdoutSetter
+	indent string
+)
+
+type groupDepthKey struct{}
+
+// GroupOptions controls how GroupE renders a group
+type GroupOptions struct {
+	// Buffer holds the output of the group until it completes, so that its title shows the elapsed time
+	// and the failure marker. Output emitted by other goroutines meanwhile is held as well.
+	Buffer bool
+}
+
+// GroupE runs f in a foldable output group and returns its error.
+//
+// The group is always ended, even when f panics, in which case the panic is propagated once the group is ended.
+// Unless options.Buffer is set, a status line holding the elapsed time and a failure marker when f fails is
+// written after the group.
+//
+// The runner does not support nested groups: groups started with the context passed to f are emulated
+// by indenting their title and the messages written with Info.
+// GroupE is meant for sequential phases, see Tasks to run concurrent ones.
+func GroupE(ctx context.Context, name string, f func(ctx context.Context) error, options ...GroupOptions) (err error) {
+	opts := GroupOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	depth, _ := ctx.Value(groupDepthKey{}).(int)
+	start := now()
+	var (
+		recorder *Recorder
+		previous Sink
+	)
+	if opts.Buffer {
+		recorder = NewRecorder()
+		previous = SwapSink(recorder)
+	} else {
+		startGroupAt(depth, name)
+	}
+	previousIndent := setIndent(strings.Repeat("  ", depth))
+	completed := false
+	defer func() {
+		setIndent(previousIndent)
+		failed := !completed || err != nil
+		elapsed := now().Sub(start).Round(time.Millisecond)
+		if !opts.Buffer {
+			endGroupAt(depth)
+			groupStatus(depth, name, elapsed, err, failed)
+			return
+		}
+		SwapSink(previous)
+		title := fmt.Sprintf("%s (%s)", name, elapsed)
+		if failed {
+			title = GroupFailureMarker + " " + title
+		}
+		stdoutSetter.Lock()
+		s := currentSinkLocked()
+		stdoutSetter.Unlock()
+		events := []Event{{Kind: "group", Message: title}}
+		if depth > 0 {
+			events = []Event{{Message: strings.Repeat("  ", depth-1) + nestedGroupMarker + " " + title}}
+		}
+		events = append(events, recorder.Events()...)
+		if depth == 0 {
+			events = append(events, Event{Kind: "endgroup"})
+		}
+		deliver(s, events)
+	}()
+	err = f(context.WithValue(ctx, groupDepthKey{}, depth+1))
+	completed = true
+	return err
+}
+
+func setIndent(i string) string {
+	stdoutSetter.Lock()
+	defer stdoutSetter.Unlock()
+	previous := indent
+	indent = i
+	return previous
+}
+
+func startGroupAt(depth int, title string) {
+	if depth == 0 {
+		StartGroup(title)
+		return
+	}
+	withIndent(depth-1, func() { Info(nestedGroupMarker + " " + title) })
+}
+
+func endGroupAt(depth int) {
+	if depth == 0 {
+		EndGroup()
+	}
+}
+
+func groupStatus(depth int, name string, elapsed time.Duration, err error, failed bool) {
+	status := fmt.Sprintf("%s %s completed in %s", GroupSuccessMarker, name, elapsed)
+	if failed {
+		status = fmt.Sprintf("%s %s failed after %s", GroupFailureMarker, name, elapsed)
+		if err != nil {
+			status += ": " + err.Error()
+		}
+	}
+	if depth > 0 {
+		depth--
+	}
+	withIndent(depth, func() { Info(status) })
+}
+
+func withIndent(depth int, f func()) {
+	previous := setIndent(strings.Repeat("  ", depth))
+	defer setIndent(previous)
+	f()
+}
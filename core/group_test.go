@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withClock(t *testing.T, step time.Duration) {
+	t.Helper()
+	origNow := now
+	t.Cleanup(func() { now = origNow })
+	current := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		current = current.Add(step)
+		return current
+	}
+}
+
+func TestGroupE(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	b := withInputs(t, nil)
+	withClock(t, 1500*time.Millisecond)
+
+	err := GroupE(context.Background(), "build", func(ctx context.Context) error {
+		Info("building")
+		return GroupE(ctx, "compile", func(ctx context.Context) error {
+			Info("compiling\nlinking")
+			Warning("careful")
+			return GroupE(ctx, "inner", func(ctx context.Context) error {
+				Info("deep")
+				return errors.New("boom")
+			})
+		})
+	})
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, `::group::build
+building
+▸ compile
+  compiling
+  linking
+::warning::careful
+  ▸ inner
+    deep
+  ✗ inner failed after 1.5s: boom
+✗ compile failed after 4.5s: boom
+::endgroup::
+✗ build failed after 7.5s: boom
+`, b.String())
+
+	b.Reset()
+	require.NoError(t, GroupE(context.Background(), "test", func(ctx context.Context) error { return nil }))
+	assert.Equal(t, "::group::test\n::endgroup::\n✓ test completed in 1.5s\n", b.String())
+	Info("not indented")
+	assert.Contains(t, b.String(), "\nnot indented\n")
+}
+
+func TestGroupEPanic(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	b := withInputs(t, nil)
+	withClock(t, time.Second)
+	assert.PanicsWithValue(t, "unexpected", func() {
+		GroupE(context.Background(), "panics", func(ctx context.Context) error {
+			return GroupE(ctx, "nested", func(ctx context.Context) error {
+				panic("unexpected")
+			})
+		})
+	})
+	assert.Equal(t, "::group::panics\n▸ nested\n✗ nested failed after 1s\n::endgroup::\n✗ panics failed after 3s\n", b.String())
+	Info("not indented")
+	assert.Contains(t, b.String(), "\nnot indented\n")
+}
+
+func TestGroupEBuffered(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	b := withInputs(t, nil)
+	withClock(t, 2*time.Second)
+	err := GroupE(context.Background(), "lint", func(ctx context.Context) error {
+		Info("linting")
+		return GroupE(ctx, "vet", func(ctx context.Context) error {
+			Info("vetting")
+			return nil
+		}, GroupOptions{Buffer: true})
+	}, GroupOptions{Buffer: true})
+	require.NoError(t, err)
+	assert.Equal(t, "::group::lint (6s)\nlinting\n▸ vet (2s)\n  vetting\n::endgroup::\n", b.String())
+
+	b.Reset()
+	err = GroupE(context.Background(), "test", func(ctx context.Context) error {
+		Error("failed")
+		return errors.New("failed")
+	}, GroupOptions{Buffer: true})
+	assert.Error(t, err)
+	assert.Equal(t, "::group::✗ test (2s)\n::error::failed\n::endgroup::\n", b.String())
+}
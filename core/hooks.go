@@ -0,0 +1,48 @@
+package core
+
+import "sync"
+
+// InputHook intercepts input lookups, for example to validate them against the action metadata.
+// It receives the name of the input along with the value found, if any, and returns the value to use.
+// A non nil error is reported by GetInput and returned by GetInputE and the other error-returning getters.
+type InputHook func(name, value string, found bool) (string, bool, error)
+
+// OutputHook validates an output before it is set.
+// When it returns an error, the output is not written and the action is marked as failed.
+type OutputHook func(name string) error
+
+var (
+	hooksAccess sync.RWMutex
+	inputHook   InputHook
+	outputHook  OutputHook
+)
+
+// SetInputHook installs the hook called on every input lookup. Pass nil to remove it.
+func SetInputHook(hook InputHook) {
+	hooksAccess.Lock()
+	inputHook = hook
+	hooksAccess.Unlock()
+}
+
+// SetOutputHook installs the hook called before setting any output. Pass nil to remove it.
+func SetOutputHook(hook OutputHook) {
+	hooksAccess.Lock()
+	outputHook = hook
+	hooksAccess.Unlock()
+}
+
+func getInputHook() InputHook {
+	hooksAccess.RLock()
+	defer hooksAccess.RUnlock()
+	return inputHook
+}
+
+func checkOutput(name string) error {
+	hooksAccess.RLock()
+	hook := outputHook
+	hooksAccess.RUnlock()
+	if hook == nil {
+		return nil
+	}
+	return hook(name)
+}
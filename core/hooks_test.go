@@ -0,0 +1,78 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputHook(t *testing.T) {
+	b := withInputs(t, map[string]string{"INPUT_SET": " value "})
+	resetStatus(t)
+	t.Cleanup(func() { SetInputHook(nil) })
+	SetInputHook(func(name, value string, found bool) (string, bool, error) {
+		switch name {
+		case "defaulted":
+			return "default", true, nil
+		case "required":
+			return "", false, ErrInputRequired
+		}
+		return value, found, nil
+	})
+
+	v, ok := GetInput("set")
+	assert.True(t, ok)
+	assert.Equal(t, "value", v)
+	v, ok = GetInput("defaulted")
+	assert.True(t, ok)
+	assert.Equal(t, "default", v)
+
+	b.Reset()
+	_, ok = GetInput("required")
+	assert.False(t, ok)
+	assert.Equal(t, "::error::input required%3A input required and not supplied\n", b.String())
+	assert.Equal(t, StatusFailed, Status())
+
+	_, err := GetInputE("required")
+	assert.EqualError(t, err, "input required: input required and not supplied")
+
+	cfg := struct {
+		Required string `input:"required"`
+	}{}
+	assert.True(t, errors.Is(BindInputs(&cfg), ErrInputRequired))
+}
+
+func TestOutputHook(t *testing.T) {
+	b := withInputs(t, nil)
+	output := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, os.WriteFile(output, nil, 0644))
+	t.Setenv(GitHubOutputFilePathEnvName, output)
+	t.Cleanup(func() {
+		SetOutputHook(nil)
+		statusAccess.Lock()
+		status = StatusSuccess
+		statusAccess.Unlock()
+	})
+	SetOutputHook(func(name string) error {
+		if name != "declared" {
+			return errors.New("output is not declared")
+		}
+		return nil
+	})
+
+	SetOutput("undeclared", "value")
+	assert.Contains(t, b.String(), "::error::unable to set output undeclared%3A output is not declared\n")
+	assert.Equal(t, StatusFailed, status)
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+
+	SetOutput("declared", "value")
+	content, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "declared<<")
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InputOptions defines the options available when reading an input, mirroring the JS toolkit InputOptions
+type InputOptions struct {
+	// Required makes the getters return ErrInputRequired when the input is not set or empty
+	Required bool
+	// TrimWhitespace controls whether leading and trailing whitespaces are removed from the value.
+	// Defaults to true
+	TrimWhitespace *bool
+}
+
+// Bool returns a pointer to b, to be used with optional boolean options
+func Bool(b bool) *bool {
+	return &b
+}
+
+func inputOptions(options []InputOptions) InputOptions {
+	if len(options) == 0 {
+		return InputOptions{}
+	}
+	return options[0]
+}
+
+// GetInputE gets the value of an input, following the JS toolkit getInput semantics.
+// Returns an empty string if the input is not set, or an *InputError wrapping ErrInputRequired
+// if the input is required and not supplied.
+func GetInputE(name string, options ...InputOptions) (string, error) {
+	opts := inputOptions(options)
+	val, _, err := lookupInput(name)
+	if err != nil {
+		return "", wrapInputError(name, err)
+	}
+	if opts.TrimWhitespace == nil || *opts.TrimWhitespace {
+		val = strings.TrimSpace(val)
+	}
+	if opts.Required && val == "" {
+		return "", &InputError{Name: name, Err: ErrInputRequired}
+	}
+	return val, nil
+}
+
+func wrapInputError(name string, err error) error {
+	inputErr := &InputError{}
+	if errors.As(err, &inputErr) {
+		return err
+	}
+	return &InputError{Name: name, Err: err}
+}
+
+// GetBoolInputE gets the value of an input and returns whether it is a truthy value per
+// the YAML 1.2 "core schema" specification: true | True | TRUE | false | False | FALSE.
+// Returns false if the input is not set. Returns an *InputError if the value is set but is not
+// a valid boolean per the YAML spec.
+func GetBoolInputE(name string, options ...InputOptions) (bool, error) {
+	val, err := GetInputE(name, options...)
+	if err != nil || val == "" {
+		return false, err
+	}
+	b, err := parseYAMLBool(val)
+	if err != nil {
+		return false, &InputError{Name: name, Err: err}
+	}
+	return b, nil
+}
+
+// GetIntInput gets the value of an input as a base 10 integer, leading zeros being ignored.
+// Returns 0 if the input is not set, or an *InputError if the value is not a valid integer.
+func GetIntInput(name string, options ...InputOptions) (int, error) {
+	val, err := GetInputE(name, options...)
+	if err != nil || val == "" {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(val, 10, strconv.IntSize)
+	if err != nil {
+		return 0, &InputError{Name: name, Err: err}
+	}
+	return int(i), nil
+}
+
+// GetDurationInput gets the value of an input as a duration, like "1m30s".
+// Returns 0 if the input is not set, or an *InputError if the value is not a valid duration.
+func GetDurationInput(name string, options ...InputOptions) (time.Duration, error) {
+	val, err := GetInputE(name, options...)
+	if err != nil || val == "" {
+		return 0, err
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, &InputError{Name: name, Err: err}
+	}
+	return d, nil
+}
+
+// GetEnumInput gets the value of an input that must be one of the allowed values.
+// Returns an empty string if the input is not set, or an *InputError if the value is not allowed.
+func GetEnumInput(name string, allowed []string, options ...InputOptions) (string, error) {
+	val, err := GetInputE(name, options...)
+	if err != nil || val == "" {
+		return "", err
+	}
+	for _, a := range allowed {
+		if val == a {
+			return val, nil
+		}
+	}
+	return "", &InputError{Name: name, Err: fmt.Errorf("%q is not one of %s", val, strings.Join(allowed, ", "))}
+}
+
+// GetJSONInput decodes the JSON value of an input into v.
+// v is left untouched if the input is not set. Returns an *InputError if the value is not valid JSON for v.
+func GetJSONInput(name string, v interface{}, options ...InputOptions) error {
+	val, err := GetInputE(name, options...)
+	if err != nil || val == "" {
+		return err
+	}
+	if err := json.Unmarshal([]byte(val), v); err != nil {
+		return &InputError{Name: name, Err: fmt.Errorf("invalid JSON value: %w", err)}
+	}
+	return nil
+}
+
+// parseYAMLBool parses a boolean following the YAML 1.2 "core schema" specification
+func parseYAMLBool(val string) (bool, error) {
+	switch val {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q does not meet YAML 1.2 \"Core Schema\" specification. Support boolean input list: `true | True | TRUE | false | False | FALSE`", val)
+}
+
+func splitLines(val string) []string {
+	lines := strings.Split(val, "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInputE(t *testing.T) {
+	withInputs(t, map[string]string{
+		"INPUT_SPACED": "  value \n",
+		"INPUT_EMPTY":  " ",
+	})
+
+	v, err := GetInputE("spaced")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", v)
+
+	v, err = GetInputE("spaced", InputOptions{TrimWhitespace: Bool(false)})
+	assert.NoError(t, err)
+	assert.Equal(t, "  value \n", v)
+
+	v, err = GetInputE("missing")
+	assert.NoError(t, err)
+	assert.Equal(t, "", v)
+
+	for _, name := range []string{"missing", "empty"} {
+		_, err = GetInputE(name, InputOptions{Required: true})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInputRequired))
+		inputErr := &InputError{}
+		require.True(t, errors.As(err, &inputErr))
+		assert.Equal(t, name, inputErr.Name)
+	}
+}
+
+func TestGetBoolInputE(t *testing.T) {
+	withInputs(t, map[string]string{
+		"INPUT_YES":   "yes",
+		"INPUT_TRUE":  "TRUE",
+		"INPUT_FALSE": "False",
+	})
+	b, err := GetBoolInputE("true")
+	assert.NoError(t, err)
+	assert.True(t, b)
+	b, err = GetBoolInputE("false")
+	assert.NoError(t, err)
+	assert.False(t, b)
+	b, err = GetBoolInputE("missing")
+	assert.NoError(t, err)
+	assert.False(t, b)
+	_, err = GetBoolInputE("missing", InputOptions{Required: true})
+	assert.True(t, errors.Is(err, ErrInputRequired))
+	_, err = GetBoolInputE("yes")
+	assert.EqualError(t, err, "input yes: \"yes\" does not meet YAML 1.2 \"Core Schema\" specification. Support boolean input list: `true | True | TRUE | false | False | FALSE`")
+	assert.False(t, GetBoolInput("yes"))
+}
+
+func TestGetIntInput(t *testing.T) {
+	withInputs(t, map[string]string{
+		"INPUT_COUNT": "42",
+		"INPUT_HEX":   "0x10",
+		"INPUT_ZEROS": "010",
+		"INPUT_EIGHT": "08",
+		"INPUT_BAD":   "4.2",
+	})
+	i, err := GetIntInput("count")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, i)
+	i, err = GetIntInput("zeros")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, i)
+	i, err = GetIntInput("eight")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, i)
+	_, err = GetIntInput("hex")
+	assert.ErrorContains(t, err, "input hex: ")
+	i, err = GetIntInput("missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, i)
+	_, err = GetIntInput("bad")
+	assert.ErrorContains(t, err, "input bad: ")
+}
+
+func TestGetDurationInput(t *testing.T) {
+	withInputs(t, map[string]string{
+		"INPUT_TIMEOUT": "1m30s",
+		"INPUT_BAD":     "90",
+	})
+	d, err := GetDurationInput("timeout")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+	_, err = GetDurationInput("bad")
+	assert.ErrorContains(t, err, "input bad: ")
+	_, err = GetDurationInput("missing", InputOptions{Required: true})
+	assert.True(t, errors.Is(err, ErrInputRequired))
+}
+
+func TestGetEnumInput(t *testing.T) {
+	withInputs(t, map[string]string{
+		"INPUT_LEVEL": "warning",
+	})
+	v, err := GetEnumInput("level", []string{"error", "warning"})
+	assert.NoError(t, err)
+	assert.Equal(t, "warning", v)
+	_, err = GetEnumInput("level", []string{"error", "notice"})
+	assert.EqualError(t, err, `input level: "warning" is not one of error, notice`)
+	v, err = GetEnumInput("missing", []string{"error"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", v)
+}
+
+func TestGetJSONInput(t *testing.T) {
+	withInputs(t, map[string]string{
+		"INPUT_MATRIX": `{"os": ["linux", "darwin"]}`,
+		"INPUT_BAD":    `{"os": `,
+	})
+	v := struct {
+		OS []string `json:"os"`
+	}{}
+	require.NoError(t, GetJSONInput("matrix", &v))
+	assert.Equal(t, []string{"linux", "darwin"}, v.OS)
+	assert.ErrorContains(t, GetJSONInput("bad", &v), "input bad: invalid JSON value")
+	assert.NoError(t, GetJSONInput("missing", &v))
+	assert.Equal(t, []string{"linux", "darwin"}, v.OS)
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// MarkdownAlignment is the alignment of a Markdown table column
+type MarkdownAlignment int
+
+const (
+	// AlignDefault lets the renderer align the column, usually to the left
+	AlignDefault MarkdownAlignment = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// MarkdownAlert is the kind of a GitHub alert block
+type MarkdownAlert string
+
+const (
+	AlertNote      MarkdownAlert = "NOTE"
+	AlertTip       MarkdownAlert = "TIP"
+	AlertImportant MarkdownAlert = "IMPORTANT"
+	AlertWarning   MarkdownAlert = "WARNING"
+	AlertCaution   MarkdownAlert = "CAUTION"
+)
+
+var (
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `{`, `\{`, `}`, `\}`, `[`, `\[`, `]`, `\]`,
+		`<`, `\<`, `>`, `\>`, `(`, `\(`, `)`, `\)`, `#`, `\#`, `+`, `\+`, `-`, `\-`, `!`, `\!`,
+		`|`, `\|`, `~`, `\~`, `&`, `\&`,
+	)
+	// orderedListMarker matches the lines that would start an ordered list, like `1. `, once escaped
+	orderedListMarker = regexp.MustCompile(`(?m)^(\s*\d+)([.)])`)
+	// setextUnderline matches the lines that would turn the previous one into a heading
+	setextUnderline = regexp.MustCompile(`(?m)^(\s*)(=+\s*)$`)
+	backtickRuns    = regexp.MustCompile("`+")
+)
+
+// EscapeMarkdown escapes s so that it renders as plain text in GitHub-Flavored Markdown
+func EscapeMarkdown(s string) string {
+	s = orderedListMarker.ReplaceAllString(markdownEscaper.Replace(s), `$1\$2`)
+	return setextUnderline.ReplaceAllString(s, `$1\$2`)
+}
+
+// MarkdownColumn describes a column of a Markdown table
+type MarkdownColumn struct {
+	Header string
+	Align  MarkdownAlignment
+}
+
+// MarkdownTask is an item of a Markdown task list
+type MarkdownTask struct {
+	Text string
+	Done bool
+}
+
+// Markdown builds GitHub-Flavored Markdown documents, for job summaries (see Summary.AddMarkdown)
+// as well as pull request or issue comments.
+//
+// Text given to the builder is escaped, unless documented otherwise, so that user content is rendered as is.
+// Blocks are separated by blank lines and footnotes are rendered at the end of the document.
+type Markdown struct {
+	blocks    []string
+	footnotes []string
+	// err is the first invalid block added to the document
+	err error
+}
+
+// NewMarkdown returns an empty Markdown document
+func NewMarkdown() *Markdown {
+	return &Markdown{}
+}
+
+func (m *Markdown) add(block string) *Markdown {
+	m.blocks = append(m.blocks, block)
+	return m
+}
+
+// String returns the Markdown document
+func (m *Markdown) String() string {
+	blocks := m.blocks
+	if len(m.footnotes) > 0 {
+		blocks = append(append([]string{}, blocks...), strings.Join(m.footnotes, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// Err returns the first invalid block added to the document, like a heading level out of range, nil otherwise.
+// Summary.AddMarkdown reports it when writing the summary.
+func (m *Markdown) Err() error {
+	return m.err
+}
+
+// Raw adds a block of Markdown as is
+func (m *Markdown) Raw(markdown string) *Markdown {
+	return m.add(markdown)
+}
+
+// Text adds a paragraph of plain text
+func (m *Markdown) Text(text string) *Markdown {
+	return m.add(EscapeMarkdown(text))
+}
+
+// Heading adds a heading of the given level, from 1 to 6. Level defaults to 1.
+// A level out of range is not added and is reported by Err as ErrInvalidHeadingLevel.
+func (m *Markdown) Heading(text string, level ...int) *Markdown {
+	lvl, err := headingLevel(level)
+	if err != nil {
+		if m.err == nil {
+			m.err = err
+		}
+		return m
+	}
+	return m.add(strings.Repeat("#", lvl) + " " + escapeInline(text))
+}
+
+// List adds a bullet list, or an ordered list when ordered is true
+func (m *Markdown) List(items []string, ordered ...bool) *Markdown {
+	lines := make([]string, 0, len(items))
+	for i, item := range items {
+		marker := "-"
+		if len(ordered) > 0 && ordered[0] {
+			marker = fmt.Sprintf("%d.", i+1)
+		}
+		lines = append(lines, marker+" "+escapeInline(item))
+	}
+	return m.add(strings.Join(lines, "\n"))
+}
+
+// TaskList adds a list of tasks rendered as checkboxes
+func (m *Markdown) TaskList(tasks []MarkdownTask) *Markdown {
+	lines := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		box := "[ ]"
+		if task.Done {
+			box = "[x]"
+		}
+		lines = append(lines, "- "+box+" "+escapeInline(task.Text))
+	}
+	return m.add(strings.Join(lines, "\n"))
+}
+
+// Table adds a table. Rows shorter than the columns are padded with empty cells, extra cells are dropped.
+func (m *Markdown) Table(columns []MarkdownColumn, rows [][]string) *Markdown {
+	row := func(cells []string) string {
+		escaped := make([]string, len(columns))
+		for i := range escaped {
+			if i < len(cells) {
+				escaped[i] = escapeInline(cells[i])
+			}
+		}
+		return "| " + strings.Join(escaped, " | ") + " |"
+	}
+	headers := make([]string, 0, len(columns))
+	delimiters := make([]string, 0, len(columns))
+	for _, c := range columns {
+		headers = append(headers, c.Header)
+		delimiters = append(delimiters, map[MarkdownAlignment]string{
+			AlignDefault: "---",
+			AlignLeft:    ":---",
+			AlignCenter:  ":---:",
+			AlignRight:   "---:",
+		}[c.Align])
+	}
+	lines := []string{row(headers), "| " + strings.Join(delimiters, " | ") + " |"}
+	for _, r := range rows {
+		lines = append(lines, row(r))
+	}
+	return m.add(strings.Join(lines, "\n"))
+}
+
+// CodeBlock adds a fenced code block, lang is an optional language for syntax highlighting.
+// The code is not escaped: the fence is made longer than any backtick sequence it holds.
+func (m *Markdown) CodeBlock(code string, lang ...string) *Markdown {
+	fence := "```"
+	for _, run := range backtickRuns.FindAllString(code, -1) {
+		if len(run) >= len(fence) {
+			fence = strings.Repeat("`", len(run)+1)
+		}
+	}
+	info := ""
+	if len(lang) > 0 {
+		info = lang[0]
+	}
+	return m.add(fence + info + "\n" + strings.TrimSuffix(code, "\n") + "\n" + fence)
+}
+
+// Mermaid adds a mermaid diagram, like `graph TD; A-->B`
+func (m *Markdown) Mermaid(diagram string) *Markdown {
+	return m.CodeBlock(diagram, "mermaid")
+}
+
+// Quote adds a quote of plain text
+func (m *Markdown) Quote(text string) *Markdown {
+	return m.add(quote(EscapeMarkdown(text)))
+}
+
+// Alert adds an alert block, highlighting text with the style of the alert kind
+func (m *Markdown) Alert(kind MarkdownAlert, text string) *Markdown {
+	return m.add(quote("[!" + string(kind) + "]\n" + EscapeMarkdown(text)))
+}
+
+// Details adds a collapsible section, labelled with summary and holding the content Markdown, not escaped.
+// Use NewMarkdown to build the content.
+func (m *Markdown) Details(summary string, content string) *Markdown {
+	return m.add("<details>\n<summary>" + html.EscapeString(summary) + "</summary>\n\n" + content + "\n\n</details>")
+}
+
+// Separator adds a thematic break
+func (m *Markdown) Separator() *Markdown {
+	return m.add("---")
+}
+
+// Footnote registers a note rendered at the end of the document, and returns the reference to include in the text
+// with Raw, like NewMarkdown().Raw(EscapeMarkdown("Build failed") + m.Footnote("logs", "See the job logs"))
+func (m *Markdown) Footnote(label, note string) string {
+	label = strings.Map(func(r rune) rune {
+		if r == ' ' || r == ']' || r == '[' || r == '^' {
+			return '-'
+		}
+		return r
+	}, label)
+	m.footnotes = append(m.footnotes, "[^"+label+"]: "+escapeInline(note))
+	return "[^" + label + "]"
+}
+
+// MarkdownLink returns an inline link to url labelled with text, to include in Markdown added with Raw
+func MarkdownLink(text, url string) string {
+	url = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(url)
+	return "[" + escapeInline(text) + "](" + url + ")"
+}
+
+// escapeInline escapes text rendered on a single line, like headings, list items and table cells
+func escapeInline(text string) string {
+	return strings.ReplaceAll(EscapeMarkdown(strings.ReplaceAll(text, "\r\n", "\n")), "\n", "<br>")
+}
+
+func quote(text string) string {
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+}
+
+// AddMarkdown adds a GitHub-Flavored Markdown document, separated from the surrounding HTML elements by blank lines.
+// An invalid document makes Write fail with its error.
+func (s *Summary) AddMarkdown(m *Markdown) *Summary {
+	if m.err != nil {
+		return s.fail(m.err)
+	}
+	if !s.IsEmptyBuffer() && !strings.HasSuffix(s.buffer.String(), EOF+EOF) {
+		s.AddEOL()
+		if !strings.HasSuffix(s.buffer.String(), EOF+EOF) {
+			s.AddEOL()
+		}
+	}
+	return s.AddRaw(m.String(), true).AddEOL()
+}
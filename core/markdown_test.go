@@ -0,0 +1,77 @@
+package core
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeMarkdown(t *testing.T) {
+	assert.Equal(t, `\*\*bold\*\* \_it\_ \`+"`code\\`"+` \[link\]\(url\) \<b\> a \| b \~\~ 1.5 \& \#1`, EscapeMarkdown("**bold** _it_ `code` [link](url) <b> a | b ~~ 1.5 & #1"))
+	assert.Equal(t, "1\\. not a list\n  2\\) nor this\n\\- nor this", EscapeMarkdown("1. not a list\n  2) nor this\n- nor this"))
+	assert.Equal(t, "title\n\\===", EscapeMarkdown("title\n==="))
+	assert.Equal(t, `C:\\path`, EscapeMarkdown(`C:\path`))
+}
+
+func TestMarkdown(t *testing.T) {
+	m := NewMarkdown()
+	note := m.Footnote("flaky tests", "Retried *3* times")
+	m.Heading("Results for `main`", 2).
+		Text("All good: 1 < 2").
+		Table([]MarkdownColumn{
+			{Header: "Package"},
+			{Header: "Status", Align: AlignCenter},
+			{Header: "Coverage", Align: AlignRight},
+			{Header: "Notes", Align: AlignLeft},
+		}, [][]string{
+			{"core", "✓", "92%", "a | b\nc"},
+			{"cache"},
+		}).
+		TaskList([]MarkdownTask{{Text: "lint", Done: true}, {Text: "[test]"}}).
+		List([]string{"first", "second"}, true).
+		CodeBlock("fmt.Println(\"```\")\n", "go").
+		Mermaid("graph TD;\n  A-->B").
+		Alert(AlertWarning, "Deprecated input\nuse `other`").
+		Quote("quoted > text").
+		Details("More <details>", NewMarkdown().List([]string{"hidden"}).String()).
+		Separator().
+		Raw("Tests passed" + note + " " + MarkdownLink("see [logs]", "https://example.com/a b"))
+
+	assert.Equal(t, "## Results for \\`main\\`\n\n"+
+		"All good: 1 \\< 2\n\n"+
+		"| Package | Status | Coverage | Notes |\n"+
+		"| --- | :---: | ---: | :--- |\n"+
+		"| core | ✓ | 92% | a \\| b<br>c |\n"+
+		"| cache |  |  |  |\n\n"+
+		"- [x] lint\n- [ ] \\[test\\]\n\n"+
+		"1. first\n2. second\n\n"+
+		"````go\nfmt.Println(\"```\")\n````\n\n"+
+		"```mermaid\ngraph TD;\n  A-->B\n```\n\n"+
+		"> [!WARNING]\n> Deprecated input\n> use \\`other\\`\n\n"+
+		"> quoted \\> text\n\n"+
+		"<details>\n<summary>More &lt;details&gt;</summary>\n\n- hidden\n\n</details>\n\n"+
+		"---\n\n"+
+		"Tests passed[^flaky-tests] [see \\[logs\\]](https://example.com/a%20b)\n\n"+
+		"[^flaky-tests]: Retried \\*3\\* times", m.String())
+}
+
+func TestSummaryAddMarkdown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	path, s := withSummaryFile(t)
+	s.AddHeading("Report").
+		AddMarkdown(NewMarkdown().Text("markdown")).
+		AddSeparator()
+	assert.Equal(t, "<h1>Report</h1>\n\nmarkdown\n\n<hr>\n", s.Stringify())
+	require.NoError(t, s.Write())
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>Report</h1>\n\nmarkdown\n\n<hr>\n", string(content))
+
+	s = &Summary{}
+	assert.Equal(t, "markdown\n\n", s.AddMarkdown(NewMarkdown().Text("markdown")).Stringify())
+}
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProblemMatcherConfig is the content of a problem matcher file, as read by the runner.
+// See https://github.com/actions/toolkit/blob/main/docs/problem-matchers.md
+type ProblemMatcherConfig struct {
+	ProblemMatcher []ProblemMatcher `json:"problemMatcher"`
+}
+
+// ProblemMatcher scans the output of the action for lines matching its patterns and turns them into annotations
+type ProblemMatcher struct {
+	// Owner identifies the matcher, for example to remove it
+	Owner string `json:"owner"`
+	// Severity is the default severity of the annotations, "error" or "warning". Defaults to "error"
+	Severity string `json:"severity,omitempty"`
+	// Pattern lists the patterns matching consecutive lines
+	Pattern []ProblemPattern `json:"pattern"`
+}
+
+// ProblemPattern is a regular expression matching one line of output.
+// The other fields are the index of the group holding the corresponding annotation property
+type ProblemPattern struct {
+	Regexp    string `json:"regexp"`
+	File      int    `json:"file,omitempty"`
+	FromPath  int    `json:"fromPath,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	Severity  int    `json:"severity,omitempty"`
+	Code      int    `json:"code,omitempty"`
+	Message   int    `json:"message,omitempty"`
+	// Loop repeats the last pattern as long as it matches, issuing one annotation per matching line
+	Loop bool `json:"loop,omitempty"`
+}
+
+// Validate checks the matcher can be used by the runner.
+// Note the runner evaluates regular expressions with the .NET engine, constructs unsupported by Go
+// like lookarounds are reported as errors.
+func (m ProblemMatcher) Validate() error {
+	if m.Owner == "" {
+		return fmt.Errorf("invalid problem matcher: missing owner")
+	}
+	if len(m.Pattern) == 0 {
+		return fmt.Errorf("invalid problem matcher %s: missing pattern", m.Owner)
+	}
+	hasMessage := false
+	for i, p := range m.Pattern {
+		if _, err := regexp.Compile(p.Regexp); err != nil {
+			return fmt.Errorf("invalid problem matcher %s: pattern %d: %w", m.Owner, i, err)
+		}
+		if p.Loop && i != len(m.Pattern)-1 {
+			return fmt.Errorf("invalid problem matcher %s: only the last pattern can loop", m.Owner)
+		}
+		if p.Loop && len(m.Pattern) == 1 {
+			return fmt.Errorf("invalid problem matcher %s: loop is only supported with multiple patterns", m.Owner)
+		}
+		hasMessage = hasMessage || p.Message != 0
+	}
+	if !hasMessage {
+		return fmt.Errorf("invalid problem matcher %s: no pattern captures the message", m.Owner)
+	}
+	return nil
+}
+
+// AddMatcher writes the problem matcher file in RUNNER_TEMP and instructs the runner to apply it
+// to the following output of the step. Returns the path of the matcher file
+func AddMatcher(m ProblemMatcher) (string, error) {
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+	dir := os.Getenv("RUNNER_TEMP")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	fd, err := os.CreateTemp(dir, "problem-matcher-*.json")
+	if err != nil {
+		return "", fmt.Errorf("unable to create problem matcher file: %w", err)
+	}
+	err = json.NewEncoder(fd).Encode(ProblemMatcherConfig{ProblemMatcher: []ProblemMatcher{m}})
+	if closeErr := fd.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fd.Name())
+		return "", fmt.Errorf("unable to write problem matcher file: %w", err)
+	}
+	Issue("add-matcher", fd.Name())
+	return fd.Name(), nil
+}
+
+// RemoveMatcher instructs the runner to stop applying the problem matcher identified by owner
+func RemoveMatcher(owner string) {
+	IssueCommand("remove-matcher", map[string]string{"owner": owner}, "")
+}
+
+// MatcherEngine applies problem matchers to lines of output in process, like the runner does,
+// so that matchers can be tested without running a workflow
+type MatcherEngine struct {
+	matchers []*matcherState
+}
+
+type matcherState struct {
+	matcher  ProblemMatcher
+	patterns []*regexp.Regexp
+	index    int
+	values   map[string]string
+}
+
+// NewMatcherEngine returns an engine applying the given matchers, in order
+func NewMatcherEngine(matchers ...ProblemMatcher) (*MatcherEngine, error) {
+	e := &MatcherEngine{}
+	for _, m := range matchers {
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+		state := &matcherState{matcher: m}
+		for _, p := range m.Pattern {
+			state.patterns = append(state.patterns, regexp.MustCompile(p.Regexp))
+		}
+		e.matchers = append(e.matchers, state)
+	}
+	return e, nil
+}
+
+// Process matches a line of output and returns the annotation it completes, if any
+func (e *MatcherEngine) Process(line string) (Annotation, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	for _, m := range e.matchers {
+		if a, ok := m.process(line); ok {
+			// like the runner, the first matcher issuing an annotation wins and resets the others
+			for _, other := range e.matchers {
+				if other != m {
+					other.reset()
+				}
+			}
+			return a, true
+		}
+	}
+	return Annotation{}, false
+}
+
+// ProcessReader matches all the lines read from r and returns the resulting annotations
+func (e *MatcherEngine) ProcessReader(r io.Reader) ([]Annotation, error) {
+	annotations := []Annotation{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxOutputsSize*2)
+	for scanner.Scan() {
+		if a, ok := e.Process(scanner.Text()); ok {
+			annotations = append(annotations, a)
+		}
+	}
+	return annotations, scanner.Err()
+}
+
+func (s *matcherState) reset() {
+	s.index = 0
+	s.values = nil
+}
+
+func (s *matcherState) process(line string) (Annotation, bool) {
+	if s.index > 0 {
+		if a, ok := s.match(line, s.index); ok {
+			return a, true
+		}
+		// the sequence is broken, the line may start a new one
+		s.reset()
+	}
+	return s.match(line, 0)
+}
+
+func (s *matcherState) match(line string, index int) (Annotation, bool) {
+	groups := s.patterns[index].FindStringSubmatch(line)
+	if groups == nil {
+		return Annotation{}, false
+	}
+	if index == 0 {
+		s.values = map[string]string{}
+	}
+	p := s.matcher.Pattern[index]
+	for name, group := range map[string]int{
+		"file": p.File, "fromPath": p.FromPath, "line": p.Line, "endLine": p.EndLine, "column": p.Column,
+		"endColumn": p.EndColumn, "severity": p.Severity, "code": p.Code, "message": p.Message,
+	} {
+		if group > 0 && group < len(groups) {
+			s.values[name] = groups[group]
+		}
+	}
+	last := len(s.patterns) - 1
+	if index < last {
+		s.index = index + 1
+		return Annotation{}, false
+	}
+	a := s.annotation()
+	if p.Loop {
+		// keep the values of the previous patterns for the next lines matched by the loop
+		values := map[string]string{}
+		for k, v := range s.values {
+			values[k] = v
+		}
+		s.index = last
+		s.values = values
+	} else {
+		s.reset()
+	}
+	return a, true
+}
+
+func (s *matcherState) annotation() Annotation {
+	atoi := func(name string) int {
+		i, _ := strconv.Atoi(s.values[name])
+		return i
+	}
+	return Annotation{
+		Level:   matcherLevel(s.values["severity"], s.matcher.Severity),
+		Message: s.values["message"],
+		Properties: AnnotationProperties{
+			Title:       s.values["code"],
+			File:        s.values["file"],
+			StartLine:   atoi("line"),
+			EndLine:     atoi("endLine"),
+			StartColumn: atoi("column"),
+			EndColumn:   atoi("endColumn"),
+		},
+	}
+}
+
+func matcherLevel(severity, dflt string) string {
+	s := strings.ToLower(severity)
+	switch {
+	case strings.HasPrefix(s, "warn"):
+		return "warning"
+	case s == "notice" || s == "info":
+		return "notice"
+	case s == "error" || s == "fatal":
+		return "error"
+	}
+	if strings.EqualFold(dflt, "warning") {
+		return "warning"
+	}
+	return "error"
+}
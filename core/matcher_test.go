@@ -0,0 +1,103 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var goVetMatcher = ProblemMatcher{
+	Owner: "go-vet",
+	Pattern: []ProblemPattern{{
+		Regexp:  `^(vet: )?(.+\.go):(\d+):(\d+): (.+)$`,
+		File:    2,
+		Line:    3,
+		Column:  4,
+		Message: 5,
+	}},
+}
+
+var eslintStylishMatcher = ProblemMatcher{
+	Owner: "eslint-stylish",
+	Pattern: []ProblemPattern{
+		{Regexp: `^([^\s].*)$`, File: 1},
+		{Regexp: `^\s+(\d+):(\d+)\s+(error|warning|info)\s+(.*)\s\s+(.*)$`, Line: 1, Column: 2, Severity: 3, Message: 4, Code: 5, Loop: true},
+	},
+}
+
+func TestProblemMatcherValidate(t *testing.T) {
+	assert.NoError(t, goVetMatcher.Validate())
+	assert.NoError(t, eslintStylishMatcher.Validate())
+	for _, m := range []ProblemMatcher{
+		{Pattern: goVetMatcher.Pattern},
+		{Owner: "empty"},
+		{Owner: "invalid", Pattern: []ProblemPattern{{Regexp: `(?<=a)b`, Message: 1}}},
+		{Owner: "no-message", Pattern: []ProblemPattern{{Regexp: `(.*)`, File: 1}}},
+		{Owner: "single-loop", Pattern: []ProblemPattern{{Regexp: `(.*)`, Message: 1, Loop: true}}},
+		{Owner: "loop-first", Pattern: []ProblemPattern{{Regexp: `(.*)`, Loop: true}, {Regexp: `(.*)`, Message: 1}}},
+	} {
+		assert.Error(t, m.Validate(), m.Owner)
+	}
+}
+
+func TestAddMatcher(t *testing.T) {
+	b := withInputs(t, nil)
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+	path, err := AddMatcher(goVetMatcher)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(path, os.Getenv("RUNNER_TEMP")))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	config := ProblemMatcherConfig{}
+	require.NoError(t, json.Unmarshal(content, &config))
+	assert.Equal(t, ProblemMatcherConfig{ProblemMatcher: []ProblemMatcher{goVetMatcher}}, config)
+	assert.Contains(t, string(content), `"owner":"go-vet"`)
+	assert.NotContains(t, string(content), `"severity"`)
+
+	RemoveMatcher("go-vet")
+	c, ok := ParseCommand(strings.Split(b.String(), "\n")[0])
+	require.True(t, ok)
+	assert.Equal(t, Command{Kind: "add-matcher", Message: path}, c)
+	c, ok = ParseCommand(strings.Split(b.String(), "\n")[1])
+	require.True(t, ok)
+	assert.Equal(t, Command{Kind: "remove-matcher", Properties: map[string]string{"owner": "go-vet"}}, c)
+
+	_, err = AddMatcher(ProblemMatcher{})
+	assert.Error(t, err)
+}
+
+func TestMatcherEngine(t *testing.T) {
+	e, err := NewMatcherEngine(goVetMatcher, eslintStylishMatcher)
+	require.NoError(t, err)
+	annotations, err := e.ProcessReader(strings.NewReader(strings.Join([]string{
+		"# github.com/actions-go/toolkit/core",
+		"vet: core/core.go:12:3: unreachable code",
+		"/src/app.js",
+		"  1:10  error    'x' is defined but never used  no-unused-vars",
+		"  3:1   warning  Unexpected console statement   no-console",
+		"",
+		"  4:1   error    orphan line without file       no-console",
+		"✖ 2 problems",
+	}, "\n")))
+	require.NoError(t, err)
+	assert.Equal(t, []Annotation{
+		{Level: "error", Message: "unreachable code", Properties: AnnotationProperties{File: "core/core.go", StartLine: 12, StartColumn: 3}},
+		{Level: "error", Message: "'x' is defined but never used", Properties: AnnotationProperties{Title: "no-unused-vars", File: "/src/app.js", StartLine: 1, StartColumn: 10}},
+		{Level: "warning", Message: "Unexpected console statement ", Properties: AnnotationProperties{Title: "no-console", File: "/src/app.js", StartLine: 3, StartColumn: 1}},
+	}, annotations)
+
+	_, err = NewMatcherEngine(ProblemMatcher{})
+	assert.Error(t, err)
+}
+
+func TestMatcherLevel(t *testing.T) {
+	assert.Equal(t, "warning", matcherLevel("Warning", ""))
+	assert.Equal(t, "notice", matcherLevel("info", "warning"))
+	assert.Equal(t, "error", matcherLevel("ERROR", "warning"))
+	assert.Equal(t, "warning", matcherLevel("", "warning"))
+	assert.Equal(t, "error", matcherLevel("unknown", ""))
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SetOutputJSON sets the JSON representation of v as the value of an output,
+// so that future steps can decode it with the fromJSON() expression.
+// Returns an error when v can't be encoded or when the output would exceed MaxOutputsSize.
+func SetOutputJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("unable to encode output %s: %w", name, err)
+	}
+	return setOutputs([][2]string{{name, string(data)}})
+}
+
+// SetOutputs sets the fields of the struct v tagged with `output:"name"` as outputs, all at once.
+// Strings are written as is, numbers and booleans using their textual representation,
+// and any other type using its JSON representation.
+// Fields tagged with the ",omitempty" option are skipped when they hold their zero value.
+// Returns an error when a field can't be encoded or when the outputs would exceed MaxOutputsSize.
+func SetOutputs(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("SetOutputs expects a struct, got %T", v)
+	}
+	outputs := [][2]string{}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("output")
+		if !ok || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "" {
+			name = field.Name
+		}
+		value := rv.Field(i)
+		if hasOption(parts[1:], "omitempty") && value.IsZero() {
+			continue
+		}
+		s, err := formatOutputValue(value)
+		if err != nil {
+			return fmt.Errorf("unable to encode output %s: %w", name, err)
+		}
+		outputs = append(outputs, [2]string{name, s})
+	}
+	return setOutputs(outputs)
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
+
+func formatOutputValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// setOutputs writes all outputs at once, falling back to the deprecated command when running outside of the runner
+func setOutputs(outputs [][2]string) error {
+	batch := fileCommands.Outputs()
+	for _, o := range outputs {
+		batch.Set(o[0], o[1])
+	}
+	err := batch.Commit()
+	if errors.Is(err, ErrMissingFileCommand) {
+		Warningf("did not find output file from environment variable %s, falling back to the deprecated command implementation", GitHubOutputFilePathEnvName)
+		for _, o := range outputs {
+			IssueCommand("set-output", map[string]string{"name": o[0]}, o[1])
+		}
+		return nil
+	}
+	return err
+}
+
+// SaveStateJSON saves the JSON representation of v as state for the post step of the current action
+func SaveStateJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("unable to encode state %s: %w", name, err)
+	}
+	err = fileCommands.SaveState(name, string(data))
+	if errors.Is(err, ErrMissingFileCommand) {
+		IssueCommand("save-state", map[string]string{"name": name}, string(data))
+		return nil
+	}
+	return err
+}
+
+// GetStateJSON decodes the state saved with SaveStateJSON into v.
+// v is left untouched when the state is not set.
+func GetStateJSON(name string, v interface{}) error {
+	state := GetState(name)
+	if state == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(state), v); err != nil {
+		return fmt.Errorf("unable to decode state %s: %w", name, err)
+	}
+	return nil
+}
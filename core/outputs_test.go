@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withOutputFiles(t *testing.T) map[string]string {
+	t.Helper()
+	files := commandFiles(t)
+	for k, v := range files {
+		t.Setenv(k, v)
+	}
+	outputsSizeAccess.Lock()
+	outputsSize = 0
+	outputsSizeAccess.Unlock()
+	t.Cleanup(func() {
+		outputsSizeAccess.Lock()
+		outputsSize = 0
+		outputsSizeAccess.Unlock()
+		statusAccess.Lock()
+		status = StatusSuccess
+		statusAccess.Unlock()
+	})
+	return files
+}
+
+func TestSetOutputJSON(t *testing.T) {
+	files := withOutputFiles(t)
+	require.NoError(t, SetOutputJSON("matrix", map[string][]string{"os": {"linux", "darwin"}}))
+	assert.Contains(t, readCommandFile(t, files, GitHubOutputFilePathEnvName), EOF+`{"os":["linux","darwin"]}`+EOF)
+	assert.Equal(t, len("matrix")+len(`{"os":["linux","darwin"]}`), OutputsSize())
+
+	assert.Error(t, SetOutputJSON("invalid", make(chan int)))
+}
+
+func TestSetOutputs(t *testing.T) {
+	files := withOutputFiles(t)
+	outputs := struct {
+		Version  string         `output:"version"`
+		Count    int            `output:"count"`
+		Ratio    float64        `output:"ratio"`
+		Released bool           `output:"released"`
+		Files    []string       `output:"files"`
+		Labels   map[string]int `output:"labels,omitempty"`
+		Empty    string         `output:"empty,omitempty"`
+		Ignored  string
+		Skipped  string `output:"-"`
+	}{
+		Version:  "1.2.3",
+		Count:    3,
+		Ratio:    0.5,
+		Released: true,
+		Files:    []string{"a.go"},
+		Ignored:  "ignored",
+		Skipped:  "skipped",
+	}
+	require.NoError(t, SetOutputs(&outputs))
+	content := readCommandFile(t, files, GitHubOutputFilePathEnvName)
+	for _, expected := range []string{
+		"version<<", EOF + "1.2.3" + EOF,
+		"count<<", EOF + "3" + EOF,
+		"ratio<<", EOF + "0.5" + EOF,
+		"released<<", EOF + "true" + EOF,
+		"files<<", EOF + `["a.go"]` + EOF,
+	} {
+		assert.Contains(t, content, expected)
+	}
+	for _, unexpected := range []string{"labels<<", "empty<<", "Ignored", "ignored", "skipped"} {
+		assert.NotContains(t, content, unexpected)
+	}
+
+	assert.Error(t, SetOutputs("not a struct"))
+}
+
+func TestOutputsSizeLimit(t *testing.T) {
+	files := withOutputFiles(t)
+	large := strings.Repeat("a", MaxOutputsSize/2)
+	require.NoError(t, SetOutputJSON("first", large))
+	before := readCommandFile(t, files, GitHubOutputFilePathEnvName)
+
+	err := SetOutputJSON("second", large)
+	assert.True(t, errors.Is(err, ErrOutputTooLarge))
+	err = SetOutputs(struct {
+		A string `output:"a"`
+		B string `output:"b"`
+	}{A: "small", B: large})
+	assert.True(t, errors.Is(err, ErrOutputTooLarge))
+	assert.Equal(t, before, readCommandFile(t, files, GitHubOutputFilePathEnvName), "outputs over the limit must not be written")
+
+	b := withInputs(t, nil)
+	SetOutput("third", large)
+	assert.Contains(t, b.String(), "::error::unable to set output third")
+}
+
+func TestStateJSON(t *testing.T) {
+	files := withOutputFiles(t)
+	state := struct {
+		PID  int      `json:"pid"`
+		Dirs []string `json:"dirs"`
+	}{PID: 42, Dirs: []string{"/tmp/a"}}
+	require.NoError(t, SaveStateJSON("cleanup", state))
+	assert.Contains(t, readCommandFile(t, files, GitHubStateFilePathEnvName), `{"pid":42,"dirs":["/tmp/a"]}`)
+
+	t.Setenv("STATE_cleanup", `{"pid":42,"dirs":["/tmp/a"]}`)
+	decoded := state
+	decoded.PID = 0
+	decoded.Dirs = nil
+	require.NoError(t, GetStateJSON("cleanup", &decoded))
+	assert.Equal(t, state, decoded)
+
+	require.NoError(t, GetStateJSON("missing", &decoded))
+	assert.Equal(t, state, decoded)
+
+	t.Setenv("STATE_invalid", `{`)
+	assert.Error(t, GetStateJSON("invalid", &decoded))
+}
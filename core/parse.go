@@ -0,0 +1,146 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var unescaper = strings.NewReplacer(
+	"%0D", "\r",
+	"%0A", "\n",
+	"%3A", ":",
+	"%2C", ",",
+	"%25", "%",
+)
+
+// Command is a workflow command, as written on the standard output by IssueCommand
+type Command struct {
+	Kind       string
+	Properties map[string]string
+	Message    string
+}
+
+// String formats the command following the github actions interface
+func (c Command) String() string {
+	return (&command{c.Kind, c.Properties, c.Message}).String()
+}
+
+// ParseCommand parses a workflow command line like `::warning file=main.go,line=1::message`,
+// unescaping its properties and message. Returns false when the line is not a workflow command.
+func ParseCommand(line string) (Command, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, cmdString) {
+		return Command{}, false
+	}
+	end := strings.Index(line[len(cmdString):], cmdString)
+	if end < 0 {
+		return Command{}, false
+	}
+	header := line[len(cmdString) : len(cmdString)+end]
+	c := Command{
+		Message: unescaper.Replace(line[len(cmdString)+end+len(cmdString):]),
+	}
+	kind, props, hasProps := strings.Cut(header, " ")
+	if kind == "" {
+		return Command{}, false
+	}
+	c.Kind = kind
+	if hasProps {
+		c.Properties = map[string]string{}
+		for _, prop := range strings.Split(props, ",") {
+			k, v, ok := strings.Cut(prop, "=")
+			if !ok || k == "" {
+				continue
+			}
+			c.Properties[k] = unescaper.Replace(v)
+		}
+	}
+	return c, true
+}
+
+// ParseCommands reads r line by line and returns all the workflow commands found, ignoring any other line
+func ParseCommands(r io.Reader) ([]Command, error) {
+	commands := []Command{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxOutputsSize*2)
+	for scanner.Scan() {
+		if c, ok := ParseCommand(scanner.Text()); ok {
+			commands = append(commands, c)
+		}
+	}
+	return commands, scanner.Err()
+}
+
+// ParseFileCommand parses the content of a key value file command, like GITHUB_OUTPUT, GITHUB_ENV or GITHUB_STATE.
+// Both the `name=value` and the heredoc `name<<DELIMITER` syntaxes are supported. Later values override earlier ones.
+func ParseFileCommand(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxOutputsSize*2)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		equal := strings.Index(line, "=")
+		heredoc := strings.Index(line, "<<")
+		if equal >= 0 && (heredoc < 0 || equal < heredoc) {
+			values[line[:equal]] = line[equal+1:]
+			continue
+		}
+		if heredoc <= 0 {
+			return nil, fmt.Errorf("line %d: invalid format %q", lineNumber, line)
+		}
+		name, delimiter := line[:heredoc], line[heredoc+2:]
+		if delimiter == "" {
+			return nil, fmt.Errorf("line %d: missing delimiter for %s", lineNumber, name)
+		}
+		start := lineNumber
+		value := []string{}
+		closed := false
+		for scanner.Scan() {
+			lineNumber++
+			l := strings.TrimRight(scanner.Text(), "\r")
+			if l == delimiter {
+				closed = true
+				break
+			}
+			value = append(value, l)
+		}
+		if !closed {
+			return nil, fmt.Errorf("line %d: matching delimiter %s not found for %s", start, delimiter, name)
+		}
+		values[name] = strings.Join(value, "\n")
+	}
+	return values, scanner.Err()
+}
+
+// ReadFileCommand reads and parses a key value file command, see ParseFileCommand
+func ReadFileCommand(path string) (map[string]string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return ParseFileCommand(fd)
+}
+
+// ReadPathFileCommand reads the paths added to the GITHUB_PATH file command, in the order they were added
+func ReadPathFileCommand(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommand(t *testing.T) {
+	c, ok := ParseCommand("::warning file=main.go,line=10,title=a%3Ab%2Cc::some%0D%0Amessage%25 with %253A\n")
+	require.True(t, ok)
+	assert.Equal(t, Command{
+		Kind:       "warning",
+		Properties: map[string]string{"file": "main.go", "line": "10", "title": "a:b,c"},
+		Message:    "some\r\nmessage% with %3A",
+	}, c)
+
+	c, ok = ParseCommand("::endgroup::")
+	require.True(t, ok)
+	assert.Equal(t, Command{Kind: "endgroup"}, c)
+
+	for _, line := range []string{"plain text", "::not a command", ":::: empty kind", ""} {
+		_, ok = ParseCommand(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestParseCommandRoundTrip(t *testing.T) {
+	b := withInputs(t, nil)
+	Error("a: message, with\nspecial %characters", AnnotationProperties{Title: "t:i,t%le", File: "main.go", StartLine: 1, EndColumn: 3})
+	Info("plain text is ignored")
+	StartGroup("group")
+	EndGroup()
+	commands, err := ParseCommands(b)
+	require.NoError(t, err)
+	assert.Equal(t, []Command{
+		{
+			Kind:       "error",
+			Properties: map[string]string{"title": "t:i,t%le", "file": "main.go", "line": "1", "endColumn": "3"},
+			Message:    "a: message, with\nspecial %characters",
+		},
+		{Kind: "group", Message: "group"},
+		{Kind: "endgroup"},
+	}, commands)
+	assert.Equal(t, "::notice::hello", Command{Kind: "notice", Message: "hello"}.String())
+}
+
+func TestParseFileCommand(t *testing.T) {
+	first, err := formatOutput("first", "multi\nline\nvalue")
+	require.NoError(t, err)
+	second, err := formatOutput("second", "contains name=value and other<<EOF")
+	require.NoError(t, err)
+	content := first + "\n" + "legacy=some=value\r\n" + second + "\n" + "first<<EOF\nreplaced\nEOF\n"
+	values, err := ParseFileCommand(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"first":  "replaced",
+		"legacy": "some=value",
+		"second": "contains name=value and other<<EOF",
+	}, values)
+
+	for _, invalid := range []string{"no-separator", "name<<EOF\nvalue\n", "name<<\nvalue\n", "<<EOF\nEOF\n"} {
+		_, err = ParseFileCommand(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestReadFileCommand(t *testing.T) {
+	files := withOutputFiles(t)
+	t.Setenv("MY_PARSED_ENV", "")
+	SetOutput("my-output", "my\nvalue")
+	SetOutput("other", "")
+	ExportVariable("MY_PARSED_ENV", "env")
+	SaveState("state", "value")
+	AddPath("/opt/bin")
+	AddPath("/usr/local/tool")
+
+	outputs, err := ReadFileCommand(files[GitHubOutputFilePathEnvName])
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"my-output": "my\nvalue", "other": ""}, outputs)
+	env, err := ReadFileCommand(files[GitHubExportEnvFilePathEnvName])
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"MY_PARSED_ENV": "env"}, env)
+	state, err := ReadFileCommand(files[GitHubStateFilePathEnvName])
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"state": "value"}, state)
+	paths, err := ReadPathFileCommand(files[GitHubPathFilePathEnvName])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/opt/bin", "/usr/local/tool"}, paths)
+
+	_, err = ReadFileCommand(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+	_, err = ReadPathFileCommand(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestParseCommandsLongLines(t *testing.T) {
+	long := strings.Repeat("a", 128*1024)
+	commands, err := ParseCommands(bytes.NewBufferString("::debug::" + long + "\n"))
+	require.NoError(t, err)
+	require.Len(t, commands, 1)
+	assert.Equal(t, long, commands[0].Message)
+}
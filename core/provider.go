@@ -0,0 +1,160 @@
+package core
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// InputProvider looks up the value of action inputs, allowing the same action to read its inputs
+// from the runner environment, from the command line or from a file.
+type InputProvider interface {
+	// LookupInput returns the raw value of the input and whether it has been found
+	LookupInput(name string) (string, bool)
+}
+
+// InputProviderFunc is an adapter to use ordinary functions as InputProvider
+type InputProviderFunc func(name string) (string, bool)
+
+// LookupInput calls f(name)
+func (f InputProviderFunc) LookupInput(name string) (string, bool) {
+	return f(name)
+}
+
+var (
+	inputProviderAccess sync.RWMutex
+	inputProvider       InputProvider
+)
+
+// DefaultInputProviders returns the providers used when none has been configured:
+// the runner INPUT_ environment variables, then the actions-go JSON inputs.
+func DefaultInputProviders() []InputProvider {
+	return []InputProvider{EnvInputs(), JSONEnvInputs()}
+}
+
+// SetInputProviders configures the providers all GetInput functions resolve inputs through.
+// Providers are queried in order and the first one finding the input wins.
+// Calling SetInputProviders without providers restores DefaultInputProviders.
+func SetInputProviders(providers ...InputProvider) {
+	inputProviderAccess.Lock()
+	defer inputProviderAccess.Unlock()
+	if len(providers) == 0 {
+		inputProvider = nil
+		return
+	}
+	inputProvider = ChainInputs(providers...)
+}
+
+func currentInputProvider() InputProvider {
+	inputProviderAccess.RLock()
+	defer inputProviderAccess.RUnlock()
+	if inputProvider == nil {
+		return ChainInputs(DefaultInputProviders()...)
+	}
+	return inputProvider
+}
+
+// ChainInputs returns a provider querying each provider in order, returning the first input found
+func ChainInputs(providers ...InputProvider) InputProvider {
+	return InputProviderFunc(func(name string) (string, bool) {
+		for _, p := range providers {
+			if val, ok := p.LookupInput(name); ok {
+				return val, true
+			}
+		}
+		return "", false
+	})
+}
+
+// EnvInputs returns the provider reading inputs from the INPUT_<NAME> environment variables set by the runner
+func EnvInputs() InputProvider {
+	return InputProviderFunc(func(name string) (string, bool) {
+		return lookupEnv(strings.ToUpper("INPUT_" + strings.Replace(name, " ", "_", -1)))
+	})
+}
+
+// JSONEnvInputs returns the provider reading inputs from the JSON object stored in the ACTION_GO_INPUTS
+// environment variable when the program started
+func JSONEnvInputs() InputProvider {
+	return InputProviderFunc(func(name string) (string, bool) {
+		val, ok := jsonInputs[name]
+		return val, ok
+	})
+}
+
+// MapInputs returns a provider reading inputs from a map, typically for tests
+func MapInputs(inputs map[string]string) InputProvider {
+	return InputProviderFunc(func(name string) (string, bool) {
+		val, ok := inputs[name]
+		return val, ok
+	})
+}
+
+// JSONInputs returns a provider reading inputs from a JSON object.
+// Values that are not strings are provided as their JSON representation.
+func JSONInputs(data []byte) (InputProvider, error) {
+	inputs, err := parseJSONInputs(data)
+	if err != nil {
+		return nil, err
+	}
+	return MapInputs(inputs), nil
+}
+
+// FileInputs returns a provider reading inputs from a YAML or JSON file holding an object.
+// Values that are not strings are provided as their JSON representation.
+func FileInputs(path string) (InputProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to decode inputs from %s: %w", path, err)
+	}
+	return MapInputs(stringifyInputs(raw)), nil
+}
+
+// FlagInputs returns a provider reading inputs from the flags explicitly set on the command line.
+// Flags are matched by input name, for example -who-to-greet=octocat.
+func FlagInputs(fs *flag.FlagSet) InputProvider {
+	return InputProviderFunc(func(name string) (string, bool) {
+		val, found := "", false
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == name {
+				val, found = f.Value.String(), true
+			}
+		})
+		return val, found
+	})
+}
+
+func parseJSONInputs(data []byte) (map[string]string, error) {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return map[string]string{}, err
+	}
+	return stringifyInputs(raw), nil
+}
+
+func stringifyInputs(raw map[string]interface{}) map[string]string {
+	r := map[string]string{}
+	for k, v := range raw {
+		switch s := v.(type) {
+		case string:
+			r[k] = s
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				Debugf("unable to serialise %s input: %v", k, err)
+				continue
+			}
+			r[k] = string(data)
+		}
+	}
+	return r
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetInputProviders(t *testing.T) {
+	withInputs(t, map[string]string{"INPUT_FROM-ENV": "env", "INPUT_BOTH": "env"})
+	t.Cleanup(func() { SetInputProviders() })
+
+	SetInputProviders(MapInputs(map[string]string{"both": "map", "only-map": " map "}), EnvInputs())
+	assert.Equal(t, "map", GetInputOrDefault("both", ""))
+	assert.Equal(t, "env", GetInputOrDefault("from-env", ""))
+	assert.Equal(t, "map", GetInputOrDefault("only-map", ""))
+	_, ok := GetInput("missing")
+	assert.False(t, ok)
+
+	SetInputProviders()
+	assert.Equal(t, "env", GetInputOrDefault("both", ""))
+	_, ok = GetInput("only-map")
+	assert.False(t, ok)
+}
+
+func TestJSONInputs(t *testing.T) {
+	p, err := JSONInputs([]byte(`{"name": "octocat", "count": 2, "list": ["a", "b"]}`))
+	require.NoError(t, err)
+	v, ok := p.LookupInput("name")
+	assert.True(t, ok)
+	assert.Equal(t, "octocat", v)
+	v, _ = p.LookupInput("count")
+	assert.Equal(t, "2", v)
+	v, _ = p.LookupInput("list")
+	assert.Equal(t, `["a","b"]`, v)
+
+	_, err = JSONInputs([]byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestFileInputs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "inputs.yml")
+	require.NoError(t, os.WriteFile(path, []byte("who-to-greet: octocat\nverbose: true\nmatrix:\n  os: [linux]\n"), 0644))
+	p, err := FileInputs(path)
+	require.NoError(t, err)
+	v, _ := p.LookupInput("who-to-greet")
+	assert.Equal(t, "octocat", v)
+	v, _ = p.LookupInput("verbose")
+	assert.Equal(t, "true", v)
+	v, _ = p.LookupInput("matrix")
+	assert.Equal(t, `{"os":["linux"]}`, v)
+
+	path = filepath.Join(dir, "inputs.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"who-to-greet": "json"}`), 0644))
+	p, err = FileInputs(path)
+	require.NoError(t, err)
+	v, _ = p.LookupInput("who-to-greet")
+	assert.Equal(t, "json", v)
+
+	_, err = FileInputs(filepath.Join(dir, "missing.yml"))
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(`[`), 0644))
+	_, err = FileInputs(path)
+	assert.Error(t, err)
+}
+
+func TestFlagInputs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("who-to-greet", "default", "")
+	fs.String("unset", "default", "")
+	require.NoError(t, fs.Parse([]string{"-who-to-greet", "octocat"}))
+	p := FlagInputs(fs)
+	v, ok := p.LookupInput("who-to-greet")
+	assert.True(t, ok)
+	assert.Equal(t, "octocat", v)
+	_, ok = p.LookupInput("unset")
+	assert.False(t, ok)
+	_, ok = p.LookupInput("undefined")
+	assert.False(t, ok)
+}
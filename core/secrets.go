@@ -0,0 +1,186 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask is the replacement of secrets in masked content, like the runner does
+const Mask = "***"
+
+type secretRegistry struct {
+	sync.RWMutex
+	secrets  map[string]bool
+	variants []string
+}
+
+var secrets = &secretRegistry{secrets: map[string]bool{}}
+
+func (r *secretRegistry) add(secret string) {
+	if secret == "" {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.secrets[secret] {
+		return
+	}
+	r.secrets[secret] = true
+	// variants are copied as list() callers may still be reading the previous slice
+	variants := append([]string{}, r.variants...)
+	known := map[string]bool{}
+	for _, v := range variants {
+		known[v] = true
+	}
+	for _, v := range secretVariants(secret) {
+		if v != "" && !known[v] {
+			known[v] = true
+			variants = append(variants, v)
+		}
+	}
+	// longest variants first so that a secret containing another one is fully masked
+	sort.SliceStable(variants, func(i, j int) bool { return len(variants[i]) > len(variants[j]) })
+	r.variants = variants
+}
+
+func (r *secretRegistry) list() []string {
+	r.RLock()
+	defer r.RUnlock()
+	return r.variants
+}
+
+func (r *secretRegistry) reset() {
+	r.Lock()
+	defer r.Unlock()
+	r.secrets = map[string]bool{}
+	r.variants = nil
+}
+
+// secretVariants returns the encoded forms of a secret that may appear in outputs
+func secretVariants(secret string) []string {
+	variants := []string{
+		secret,
+		base64.StdEncoding.EncodeToString([]byte(secret)),
+		base64.RawStdEncoding.EncodeToString([]byte(secret)),
+		base64.URLEncoding.EncodeToString([]byte(secret)),
+		base64.RawURLEncoding.EncodeToString([]byte(secret)),
+		url.QueryEscape(secret),
+		url.PathEscape(secret),
+	}
+	if data, err := json.Marshal(secret); err == nil {
+		variants = append(variants, string(data[1:len(data)-1]))
+	}
+	return variants
+}
+
+// Secrets returns all the values registered with SetSecret
+func Secrets() []string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+	r := make([]string, 0, len(secrets.secrets))
+	for s := range secrets.secrets {
+		r = append(r, s)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// MaskSecrets replaces the secrets registered with SetSecret, as well as their base64, URL-encoded
+// and JSON-escaped forms, with Mask
+func MaskSecrets(s string) string {
+	variants := secrets.list()
+	if len(variants) == 0 {
+		return s
+	}
+	out, _ := maskBytes([]byte(s), variants, true)
+	return string(out)
+}
+
+// maskBytes masks all variants in buf. Unless final is set, it stops at the first trailing bytes that may be
+// the beginning of a secret and returns them to be masked along with the following content.
+func maskBytes(buf []byte, variants []string, final bool) ([]byte, []byte) {
+	out := make([]byte, 0, len(buf))
+	i := 0
+scan:
+	for i < len(buf) {
+		rest := buf[i:]
+		partial := false
+		for _, v := range variants {
+			if len(rest) >= len(v) {
+				if string(rest[:len(v)]) == v {
+					out = append(out, Mask...)
+					i += len(v)
+					continue scan
+				}
+			} else if !final && strings.HasPrefix(v, string(rest)) {
+				partial = true
+			}
+		}
+		if partial {
+			return out, append([]byte{}, rest...)
+		}
+		out = append(out, buf[i])
+		i++
+	}
+	return out, nil
+}
+
+// MaskingWriter is an io.Writer redacting registered secrets before writing to the underlying writer.
+// Secrets split across several writes are masked as well: the trailing bytes that may be the beginning
+// of a secret are held until the next write, Flush or Close.
+//
+// Use SetStdout(NewMaskingWriter(os.Stdout)) to mask all messages written by this package.
+type MaskingWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	pending []byte
+}
+
+// NewMaskingWriter returns a MaskingWriter writing to w
+func NewMaskingWriter(w io.Writer) *MaskingWriter {
+	return &MaskingWriter{w: w}
+}
+
+// Write masks secrets in p and writes the result to the underlying writer
+func (m *MaskingWriter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = append(m.pending, p...)
+	out, keep := maskBytes(m.pending, secrets.list(), false)
+	m.pending = keep
+	if len(out) > 0 {
+		if _, err := m.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the bytes held as a potential beginning of a secret
+func (m *MaskingWriter) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pending) == 0 {
+		return nil
+	}
+	out, _ := maskBytes(m.pending, secrets.list(), true)
+	m.pending = nil
+	_, err := m.w.Write(out)
+	return err
+}
+
+// Close flushes the pending bytes, and closes the underlying writer when it is an io.Closer
+func (m *MaskingWriter) Close() error {
+	if err := m.Flush(); err != nil {
+		return err
+	}
+	if c, ok := m.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withSecrets(t *testing.T, values ...string) *bytes.Buffer {
+	t.Helper()
+	b := withInputs(t, nil)
+	secrets.reset()
+	t.Cleanup(secrets.reset)
+	for _, v := range values {
+		SetSecret(v)
+	}
+	return b
+}
+
+func TestSetSecretRegistersSecrets(t *testing.T) {
+	b := withSecrets(t, "s3cr3t", "", "s3cr3t", "other")
+	assert.Equal(t, []string{"other", "s3cr3t"}, Secrets())
+	assert.Equal(t, "::add-mask::s3cr3t\n::add-mask::\n::add-mask::s3cr3t\n::add-mask::other\n", b.String())
+}
+
+func TestMaskSecrets(t *testing.T) {
+	withSecrets(t, "p@ss word/\"x\"", "abc", "abcdef")
+	assert.Equal(t, "plain", MaskSecrets("plain"))
+	for _, encoded := range []string{
+		"p@ss word/\"x\"",
+		base64.StdEncoding.EncodeToString([]byte("p@ss word/\"x\"")),
+		"p%40ss+word%2F%22x%22",
+		"p@ss%20word%2F%22x%22",
+		`p@ss word/\"x\"`,
+	} {
+		assert.Equal(t, "token=*** end", MaskSecrets("token="+encoded+" end"), encoded)
+	}
+	assert.Equal(t, "*** and ***", MaskSecrets("abcdef and abc"))
+}
+
+func TestMaskingWriter(t *testing.T) {
+	withSecrets(t, "s3cr3t")
+	out := bytes.NewBuffer(nil)
+	w := NewMaskingWriter(out)
+
+	for _, chunk := range []string{"hello s3", "cr", "3t world s", "3cr3", "x and s3"} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.Equal(t, "hello *** world s3cr3x and ", out.String())
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "hello *** world s3cr3x and s3", out.String())
+	require.NoError(t, w.Close())
+}
+
+func TestMaskingStdout(t *testing.T) {
+	b := withSecrets(t)
+	out := NewMaskingWriter(b)
+	SetStdout(out)
+	t.Cleanup(func() { SetStdout(os.Stdout) })
+
+	SetSecret("s3cr3t")
+	Info("the secret is s3cr3t")
+	Warningf("token %s", base64.StdEncoding.EncodeToString([]byte("s3cr3t")))
+	require.NoError(t, out.Flush())
+	assert.Equal(t, "::add-mask::s3cr3t\nthe secret is ***\n::warning::token ***\n", b.String())
+}
+
+func TestMaskingSummary(t *testing.T) {
+	withSecrets(t, "s3cr3t")
+	name, s := withSummaryFile(t)
+	require.NoError(t, s.AddRaw("summary with s3cr3t").Write())
+	AddStepSummary(" and s3cr3t again")
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "summary with *** and *** again\n", string(content))
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout is the time given to the shutdown hooks when the action receives a termination signal.
+// The runner kills the action 7.5 seconds after sending SIGINT on cancellation.
+const DefaultShutdownTimeout = 5 * time.Second
+
+var (
+	exit            = os.Exit
+	shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+	rootOnce   sync.Once
+	rootCtx    context.Context
+	rootCancel context.CancelFunc
+
+	shutdownAccess  sync.Mutex
+	shutdownHooks   []*shutdownHook
+	shutdownTimeout = DefaultShutdownTimeout
+)
+
+type shutdownHook struct {
+	name string
+	f    func(ctx context.Context) error
+}
+
+// HandleSignals installs the handler of the SIGINT and SIGTERM signals and returns the root context of the action,
+// cancelled when the runner cancels the workflow. action.Run calls it for the actions it runs.
+//
+// On the first signal, the context is cancelled, the shutdown hooks registered with OnShutdown are run
+// and the process exits with StatusFailed. A second signal terminates the process immediately.
+// Only actions should call HandleSignals: other programs keep their own signal handling.
+func HandleSignals() context.Context {
+	rootOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		shutdownAccess.Lock()
+		rootCtx, rootCancel = ctx, cancel
+		shutdownAccess.Unlock()
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, shutdownSignals...)
+		go func() {
+			sig := <-signals
+			signal.Stop(signals)
+			onSignal(sig)
+		}()
+	})
+	return Context()
+}
+
+// Context returns the root context of the action once HandleSignals has been called, context.Background otherwise.
+// The functions of the toolkit packages without a context argument, like cache.DownloadTool, use it,
+// so that they do not install a signal handler in programs that are not actions.
+func Context() context.Context {
+	shutdownAccess.Lock()
+	defer shutdownAccess.Unlock()
+	if rootCtx == nil {
+		return context.Background()
+	}
+	return rootCtx
+}
+
+func onSignal(sig os.Signal) {
+	Warningf("received %s, shutting down", sig)
+	shutdownAccess.Lock()
+	cancel := rootCancel
+	shutdownAccess.Unlock()
+	cancel()
+	shutdownAccess.Lock()
+	timeout := shutdownTimeout
+	shutdownAccess.Unlock()
+	if err := Shutdown(timeout); err != nil {
+		Error(err.Error())
+	}
+	Flush()
+	exit(StatusFailed)
+}
+
+// SetShutdownTimeout sets the time given to the shutdown hooks when the action receives a termination signal,
+// DefaultShutdownTimeout by default
+func SetShutdownTimeout(timeout time.Duration) {
+	shutdownAccess.Lock()
+	shutdownTimeout = timeout
+	shutdownAccess.Unlock()
+}
+
+// OnShutdown registers f to be run by Shutdown, typically to remove partially written files.
+// The returned function unregisters f, once the work it cleans up is complete.
+func OnShutdown(name string, f func(ctx context.Context) error) func() {
+	hook := &shutdownHook{name: name, f: f}
+	shutdownAccess.Lock()
+	shutdownHooks = append(shutdownHooks, hook)
+	shutdownAccess.Unlock()
+	return func() {
+		shutdownAccess.Lock()
+		defer shutdownAccess.Unlock()
+		for i, h := range shutdownHooks {
+			if h == hook {
+				shutdownHooks = append(shutdownHooks[:i:i], shutdownHooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Shutdown runs the registered shutdown hooks once, in the reverse order of their registration.
+// Hooks are given a context expiring after timeout, the hooks not completed by then are reported as failed
+// and the remaining ones are not run.
+func Shutdown(timeout time.Duration) error {
+	shutdownAccess.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	shutdownAccess.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook %s not run: %w", hook.name, ctx.Err()))
+			continue
+		}
+		done := make(chan error, 1)
+		go func() { done <- hook.f(ctx) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, fmt.Errorf("shutdown hook %s: %w", hook.name, err))
+			}
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("shutdown hook %s: %w", hook.name, ctx.Err()))
+		}
+	}
+	return errors.Join(errs...)
+}
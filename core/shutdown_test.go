@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withShutdownHooks(t *testing.T) {
+	t.Helper()
+	shutdownAccess.Lock()
+	previous := shutdownHooks
+	shutdownHooks = nil
+	shutdownAccess.Unlock()
+	t.Cleanup(func() {
+		shutdownAccess.Lock()
+		shutdownHooks = previous
+		shutdownAccess.Unlock()
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	withShutdownHooks(t)
+	calls := []string{}
+	hook := func(name string, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline)
+			calls = append(calls, name)
+			return err
+		}
+	}
+	OnShutdown("first", hook("first", nil))
+	remove := OnShutdown("removed", hook("removed", nil))
+	OnShutdown("failing", hook("failing", errors.New("boom")))
+	OnShutdown("last", hook("last", nil))
+	remove()
+	remove()
+
+	err := Shutdown(time.Second)
+	assert.EqualError(t, err, "shutdown hook failing: boom")
+	assert.Equal(t, []string{"last", "failing", "first"}, calls)
+
+	// hooks are run once
+	assert.NoError(t, Shutdown(time.Second))
+	assert.Len(t, calls, 3)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	withShutdownHooks(t)
+	called := false
+	OnShutdown("not run", func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	OnShutdown("blocking", func(ctx context.Context) error {
+		select {}
+	})
+	err := Shutdown(10 * time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "shutdown hook blocking: ")
+	assert.ErrorContains(t, err, "shutdown hook not run not run: ")
+	assert.False(t, called)
+}
+
+func TestOnSignal(t *testing.T) {
+	withShutdownHooks(t)
+	b := withInputs(t, nil)
+	code := -1
+	exit = func(c int) { code = c }
+	ctx, cancel := context.WithCancel(context.Background())
+	rootCancel = cancel
+	t.Cleanup(func() {
+		exit = os.Exit
+		rootCancel = nil
+	})
+	SetShutdownTimeout(time.Second)
+	t.Cleanup(func() { SetShutdownTimeout(DefaultShutdownTimeout) })
+
+	cleaned := false
+	OnShutdown("cleanup", func(hookCtx context.Context) error {
+		assert.Error(t, ctx.Err())
+		cleaned = true
+		return errors.New("failed")
+	})
+	onSignal(os.Interrupt)
+
+	require.Error(t, ctx.Err())
+	assert.True(t, cleaned)
+	assert.Equal(t, StatusFailed, code)
+	assert.Contains(t, b.String(), "::warning::received interrupt%2C shutting down\n")
+	assert.Contains(t, b.String(), "::error::shutdown hook cleanup%3A failed\n")
+}
+
+func TestContextWithoutHandleSignals(t *testing.T) {
+	shutdownAccess.Lock()
+	handled := rootCtx != nil
+	shutdownAccess.Unlock()
+	if handled {
+		t.Skip("HandleSignals has been called by another test")
+	}
+	assert.Equal(t, context.Background(), Context())
+}
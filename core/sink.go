@@ -0,0 +1,287 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	// sink receives every message written by this package, guarded by stdoutSetter.
+	// A nil sink writes the runner protocol to stdout, or renders it for humans when running locally, see ConsoleSink.
+	sink Sink
+
+	// emitAccess guards the batches of events waiting to be delivered, see deliver
+	emitAccess sync.Mutex
+	emitQueue  []emitBatch
+	emitting   bool
+	// sinkErrors receives the errors returned by the sinks
+	sinkErrors io.Writer = os.Stderr
+)
+
+type emitBatch struct {
+	sink   Sink
+	events []Event
+}
+
+// Event is a message written to the workflow log: either a workflow command, or plain text when Kind is empty
+type Event struct {
+	// Kind is the workflow command, like `warning` or `group`, empty for plain text
+	Kind string `json:"kind,omitempty"`
+	// Properties holds the command properties, like the annotation file and line
+	Properties map[string]string `json:"properties,omitempty"`
+	// Message is the command message, or the plain text
+	Message string `json:"message"`
+}
+
+// IsText returns whether the event is plain text rather than a workflow command
+func (e Event) IsText() bool {
+	return e.Kind == ""
+}
+
+// String formats the event following the runner stdout protocol
+func (e Event) String() string {
+	if e.IsText() {
+		return e.Message
+	}
+	return (&command{e.Kind, e.Properties, e.Message}).String()
+}
+
+// Annotation returns the annotation issued by an error, warning or notice event
+func (e Event) Annotation() (Annotation, bool) {
+	switch e.Kind {
+	case "error", "warning", "notice":
+		return Annotation{Level: e.Kind, Message: e.Message, Properties: propertiesToAnnotation(e.Properties)}, true
+	}
+	return Annotation{}, false
+}
+
+// Sink receives the messages written by this package.
+//
+// Events are delivered one at a time, without holding any lock of this package: a sink may log through this package,
+// like calling Debugf when a write fails, the messages are then delivered once the current event has been emitted.
+// Errors returned by Emit are reported on the standard error.
+type Sink interface {
+	Emit(e Event) error
+}
+
+// SinkFunc is a function implementing Sink
+type SinkFunc func(e Event) error
+
+// Emit calls f(e)
+func (f SinkFunc) Emit(e Event) error {
+	return f(e)
+}
+
+// SetSink routes all messages written by this package, and by the packages built on top of it, to s.
+// A nil sink restores the default one, writing the runner protocol to the standard output (see SetStdout and ConsoleSink).
+func SetSink(s Sink) {
+	stdoutSetter.Lock()
+	sink = s
+	stdoutSetter.Unlock()
+}
+
+// CurrentSink returns the sink receiving the messages, typically to combine it with TeeSink
+func CurrentSink() Sink {
+	stdoutSetter.Lock()
+	defer stdoutSetter.Unlock()
+	return currentSinkLocked()
+}
+
+func currentSinkLocked() Sink {
+	if sink != nil {
+		return sink
+	}
+	if stdout == os.Stdout {
+		if console := consoleSink(); console != nil {
+			return console
+		}
+	}
+	return stdoutSink{stdout}
+}
+
+// SwapSink sets the sink like SetSink and returns the previous one, nil when it was the default sink.
+// Unlike CurrentSink, the returned value restores the default sink when given back to SwapSink.
+func SwapSink(s Sink) Sink {
+	stdoutSetter.Lock()
+	defer stdoutSetter.Unlock()
+	previous := sink
+	sink = s
+	return previous
+}
+
+// Emit sends events to the current sink, at once: events emitted concurrently are not interleaved with them.
+// Plain text is indented in emulated nested groups, see GroupE.
+func Emit(events ...Event) {
+	stdoutSetter.Lock()
+	s := currentSinkLocked()
+	batch := make([]Event, 0, len(events))
+	for _, e := range events {
+		if e.IsText() && indent != "" {
+			e.Message = indent + strings.ReplaceAll(e.Message, "\n", "\n"+indent)
+		}
+		batch = append(batch, e)
+	}
+	stdoutSetter.Unlock()
+	deliver(s, batch)
+}
+
+// deliver emits the events to s, after the batches queued before them and without interleaving them with others.
+// The first caller delivers the queued batches without holding emitAccess, so that sinks can log through this package:
+// events emitted meanwhile, including by the sinks themselves, are queued and delivered by the same caller.
+func deliver(s Sink, events []Event) {
+	emitAccess.Lock()
+	emitQueue = append(emitQueue, emitBatch{sink: s, events: events})
+	if emitting {
+		emitAccess.Unlock()
+		return
+	}
+	emitting = true
+	defer func() {
+		emitting = false
+		emitAccess.Unlock()
+	}()
+	for len(emitQueue) > 0 {
+		batch := emitQueue[0]
+		emitQueue = emitQueue[1:]
+		emitAccess.Unlock()
+		for _, e := range batch.events {
+			if err := batch.sink.Emit(e); err != nil {
+				fmt.Fprintf(sinkErrors, "unable to emit %q: %v\n", e.String(), err)
+			}
+		}
+		emitAccess.Lock()
+	}
+}
+
+// stdoutSink writes the runner protocol to the writer set with SetStdout
+type stdoutSink struct {
+	w io.Writer
+}
+
+func (s stdoutSink) Emit(e Event) error {
+	_, err := fmt.Fprintln(s.w, e.String())
+	return err
+}
+
+// WriterSink writes events to a writer following the runner stdout protocol, one line per event
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a WriterSink writing to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Emit writes the event
+func (s *WriterSink) Emit(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintln(s.w, e.String())
+	return err
+}
+
+// JSONSink writes events to a writer as JSON lines, like {"kind":"warning","properties":{"file":"main.go"},"message":"..."}
+type JSONSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONSink returns a JSONSink writing to w
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{enc: json.NewEncoder(w)}
+}
+
+// Emit writes the event
+func (s *JSONSink) Emit(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(e)
+}
+
+// Recorder is a Sink keeping the events in memory
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewRecorder returns an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Emit records the event
+func (r *Recorder) Emit(e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+// Events returns the recorded events, in order
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// Commands returns the recorded workflow commands of the given kind
+func (r *Recorder) Commands(kind string) []Event {
+	events := []Event{}
+	for _, e := range r.Events() {
+		if e.Kind == kind && !e.IsText() {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// String returns the recorded events following the runner stdout protocol
+func (r *Recorder) String() string {
+	b := strings.Builder{}
+	for _, e := range r.Events() {
+		b.WriteString(e.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Reset drops the recorded events
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.events = nil
+	r.mu.Unlock()
+}
+
+// Replay emits the recorded events to s, stopping at the first error
+func (r *Recorder) Replay(s Sink) error {
+	for _, e := range r.Events() {
+		if err := s.Emit(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type teeSink []Sink
+
+// TeeSink returns a Sink emitting the events to all sinks, reporting all their errors
+func TeeSink(sinks ...Sink) Sink {
+	return teeSink(sinks)
+}
+
+func (t teeSink) Emit(e Event) error {
+	var errs []error
+	for _, s := range t {
+		if err := s.Emit(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
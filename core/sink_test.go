@@ -0,0 +1,152 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withSink(t *testing.T, s Sink) {
+	t.Helper()
+	previous := SwapSink(s)
+	t.Cleanup(func() { SwapSink(previous) })
+}
+
+func TestSinkEvents(t *testing.T) {
+	rec := NewRecorder()
+	withSink(t, rec)
+
+	Info("hello")
+	Warning("careful", AnnotationProperties{File: "main.go"})
+	Group("build", func() { Debug("details") })
+
+	assert.Equal(t, []Event{
+		{Message: "hello"},
+		{Kind: "warning", Properties: map[string]string{"file": "main.go"}, Message: "careful"},
+		{Kind: "group", Message: "build"},
+		{Kind: "debug", Message: "details"},
+		{Kind: "endgroup"},
+	}, rec.Events())
+	assert.Len(t, rec.Commands("warning"), 1)
+	assert.Empty(t, rec.Commands(""))
+	assert.Equal(t, "hello\n::warning file=main.go::careful\n::group::build\n::debug::details\n::endgroup::\n", rec.String())
+
+	rec.Reset()
+	assert.Empty(t, rec.Events())
+}
+
+func TestSinkGroupE(t *testing.T) {
+	rec := NewRecorder()
+	withSink(t, rec)
+	withClock(t, 0)
+
+	require.NoError(t, GroupE(context.Background(), "outer", func(ctx context.Context) error {
+		return GroupE(ctx, "inner", func(ctx context.Context) error {
+			Info("a")
+			return nil
+		}, GroupOptions{Buffer: true})
+	}, GroupOptions{Buffer: true}))
+	assert.Equal(t, []Event{
+		{Kind: "group", Message: "outer (0s)"},
+		{Message: "▸ inner (0s)"},
+		{Message: "  a"},
+		{Kind: "endgroup"},
+	}, rec.Events())
+}
+
+func TestWriterSinks(t *testing.T) {
+	text := &bytes.Buffer{}
+	lines := &bytes.Buffer{}
+	rec := NewRecorder()
+	withSink(t, TeeSink(NewWriterSink(text), NewJSONSink(lines), rec))
+
+	Info("plain, text")
+	IssueCommand("error", map[string]string{"line": "1"}, "fail")
+
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, "plain, text\n::error line=1::fail\n", text.String())
+	}
+	assert.Equal(t, `{"message":"plain, text"}`+"\n"+`{"kind":"error","properties":{"line":"1"},"message":"fail"}`+"\n", lines.String())
+
+	replayed := &bytes.Buffer{}
+	require.NoError(t, rec.Replay(NewWriterSink(replayed)))
+	assert.Equal(t, text.String(), replayed.String())
+}
+
+func TestTeeSinkErrors(t *testing.T) {
+	rec := NewRecorder()
+	err1 := errors.New("first")
+	err2 := errors.New("second")
+	failing := func(err error) Sink {
+		return SinkFunc(func(Event) error { return err })
+	}
+	err := TeeSink(failing(err1), rec, failing(err2)).Emit(Event{Message: "x"})
+	assert.ErrorIs(t, err, err1)
+	assert.ErrorIs(t, err, err2)
+	assert.Len(t, rec.Events(), 1)
+}
+
+func TestSetSink(t *testing.T) {
+	b := withInputs(t, nil)
+	rec := NewRecorder()
+	SetSink(rec)
+	assert.Equal(t, rec, CurrentSink())
+	Info("recorded")
+	SetSink(nil)
+	assert.Equal(t, stdoutSink{b}, CurrentSink())
+	Info("written")
+	assert.Equal(t, "written\n", b.String())
+	assert.Equal(t, []Event{{Message: "recorded"}}, rec.Events())
+
+	SetSink(rec)
+	SetStdout(b)
+	Info("again")
+	assert.Len(t, rec.Events(), 1)
+}
+
+func TestSinkLoggingThroughCore(t *testing.T) {
+	withInputs(t, nil)
+	errs := &bytes.Buffer{}
+	previous := sinkErrors
+	sinkErrors = errs
+	t.Cleanup(func() { sinkErrors = previous })
+
+	rec := NewRecorder()
+	withSink(t, SinkFunc(func(e Event) error {
+		if e.Kind == "debug" {
+			return rec.Emit(e)
+		}
+		if err := rec.Emit(e); err != nil {
+			return err
+		}
+		if e.Message == "fail" {
+			Debugf("unable to write %s", e.Message)
+			return errors.New("disk full")
+		}
+		return nil
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		Info("fail")
+		Info("next")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("emitting from a sink deadlocked")
+	}
+	assert.Equal(t, []Event{
+		{Message: "fail"},
+		{Kind: "debug", Message: "unable to write fail"},
+		{Message: "next"},
+	}, rec.Events())
+	assert.Equal(t, "unable to emit \"fail\": disk full\n", errs.String())
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// LevelNotice is the slog level written as notice annotations by SlogHandler, between slog.LevelInfo and slog.LevelWarn
+const LevelNotice = slog.LevelInfo + 2
+
+// SlogHandlerOptions configures a SlogHandler
+type SlogHandlerOptions struct {
+	// Level is the minimum level of the records to write.
+	// Defaults to slog.LevelDebug when IsDebug() is true, slog.LevelInfo otherwise.
+	Level slog.Leveler
+	// InfoAsNotice writes records at slog.LevelInfo as notice annotations instead of plain log lines
+	InfoAsNotice bool
+}
+
+// SlogHandler is a slog.Handler writing records as workflow commands:
+// debug records as debug messages, info records as plain log lines, LevelNotice, warning and error records as annotations.
+//
+// The well-known "file", "line", "endLine", "col", "endColumn" and "title" attributes are turned into annotation
+// properties, remaining attributes are appended to the message as key=value pairs.
+type SlogHandler struct {
+	opts   SlogHandlerOptions
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewSlogHandler returns a SlogHandler. A nil opts uses the default options
+func NewSlogHandler(opts *SlogHandlerOptions) *SlogHandler {
+	h := &SlogHandler{}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// Enabled reports whether records at level are written
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.opts.Level != nil {
+		return level >= h.opts.Level.Level()
+	}
+	if IsDebug() {
+		return true
+	}
+	return level >= slog.LevelInfo
+}
+
+// Handle writes the record as a workflow command
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	annotate := r.Level >= LevelNotice || (h.opts.InfoAsNotice && r.Level >= slog.LevelInfo)
+	props := AnnotationProperties{}
+	hasProps := false
+	var text strings.Builder
+	text.WriteString(r.Message)
+	appendAttr := func(prefix string, a slog.Attr) {
+		if annotate && prefix == "" && setAnnotationAttr(&props, a) {
+			hasProps = true
+			return
+		}
+		writeSlogAttr(&text, prefix, a)
+	}
+	prefix := ""
+	for _, a := range h.attrs {
+		appendAttr("", a)
+	}
+	if len(h.groups) > 0 {
+		prefix = strings.Join(h.groups, ".") + "."
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(prefix, a)
+		return true
+	})
+
+	message := text.String()
+	var properties []AnnotationProperties
+	if hasProps {
+		properties = append(properties, props)
+	}
+	switch {
+	case r.Level >= slog.LevelError:
+		Error(message, properties...)
+	case r.Level >= slog.LevelWarn:
+		Warning(message, properties...)
+	case annotate:
+		Notice(message, properties...)
+	case r.Level >= slog.LevelInfo:
+		Info(message)
+	default:
+		Debug(message)
+	}
+	return nil
+}
+
+// WithAttrs returns a handler adding attrs to all records
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	c := h.clone()
+	if len(h.groups) > 0 {
+		attrs = []slog.Attr{{Key: strings.Join(h.groups, "."), Value: slog.GroupValue(attrs...)}}
+	}
+	c.attrs = append(c.attrs, attrs...)
+	return c
+}
+
+// WithGroup returns a handler qualifying the keys of the following attributes with name
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := h.clone()
+	c.groups = append(c.groups, name)
+	return c
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
+	return &SlogHandler{
+		opts:   h.opts,
+		attrs:  append([]slog.Attr{}, h.attrs...),
+		groups: append([]string{}, h.groups...),
+	}
+}
+
+func setAnnotationAttr(props *AnnotationProperties, a slog.Attr) bool {
+	v := a.Value.Resolve()
+	switch a.Key {
+	case "title":
+		props.Title = v.String()
+	case "file":
+		props.File = v.String()
+	case "line":
+		return setIntAttr(&props.StartLine, v)
+	case "endLine":
+		return setIntAttr(&props.EndLine, v)
+	case "col":
+		return setIntAttr(&props.StartColumn, v)
+	case "endColumn":
+		return setIntAttr(&props.EndColumn, v)
+	default:
+		return false
+	}
+	return true
+}
+
+func setIntAttr(dst *int, v slog.Value) bool {
+	switch v.Kind() {
+	case slog.KindInt64:
+		*dst = int(v.Int64())
+	case slog.KindUint64:
+		*dst = int(v.Uint64())
+	default:
+		i, err := strconv.Atoi(v.String())
+		if err != nil {
+			return false
+		}
+		*dst = i
+	}
+	return true
+}
+
+func writeSlogAttr(w *strings.Builder, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			writeSlogAttr(w, prefix, ga)
+		}
+		return
+	}
+	s := v.String()
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		s = strconv.Quote(s)
+	}
+	fmt.Fprintf(w, " %s%s=%s", prefix, a.Key, s)
+}
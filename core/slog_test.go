@@ -0,0 +1,63 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogHandler(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	b := withInputs(t, nil)
+	t.Setenv("RUNNER_DEBUG", "")
+	logger := slog.New(NewSlogHandler(nil))
+
+	logger.Debug("hidden")
+	logger.Info("plain", "count", 3, "name", "two words")
+	logger.Log(context.Background(), LevelNotice, "noticed", "title", "Title")
+	logger.Warn("careful", "file", "main.go", "line", 10, "col", "5", "reason", "deprecated")
+	logger.Error("failed", slog.Group("req", "id", 1), "file", "a.go", "endLine", uint64(12))
+	logger.With("file", "b.go").WithGroup("g").Error("grouped", "line", 3, "k", "")
+
+	commands, err := ParseCommands(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, []Command{
+		{Kind: "notice", Properties: map[string]string{"title": "Title"}, Message: "noticed"},
+		{Kind: "warning", Properties: map[string]string{"file": "main.go", "line": "10", "col": "5"}, Message: "careful reason=deprecated"},
+		{Kind: "error", Properties: map[string]string{"file": "a.go", "endLine": "12"}, Message: "failed req.id=1"},
+		{Kind: "error", Properties: map[string]string{"file": "b.go"}, Message: `grouped g.line=3 g.k=""`},
+	}, commands)
+	assert.NotContains(t, b.String(), "hidden")
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	b := withInputs(t, nil)
+	h := NewSlogHandler(nil)
+
+	t.Setenv("RUNNER_DEBUG", "1")
+	assert.True(t, h.Enabled(context.Background(), slog.LevelDebug))
+	slog.New(h).Debug("visible", "file", "main.go")
+	assert.Contains(t, b.String(), "::debug::visible file=main.go")
+
+	t.Setenv("RUNNER_DEBUG", "")
+	assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelInfo))
+
+	h = NewSlogHandler(&SlogHandlerOptions{Level: slog.LevelWarn, InfoAsNotice: true})
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+
+	b.Reset()
+	h = NewSlogHandler(&SlogHandlerOptions{InfoAsNotice: true})
+	slog.New(h).Info("info", "file", "main.go")
+	c, ok := ParseCommand(b.String())
+	require.True(t, ok)
+	assert.Equal(t, Command{Kind: "notice", Properties: map[string]string{"file": "main.go"}, Message: "info"}, c)
+}
@@ -1,15 +1,33 @@
 package core
 
 import (
+	"errors"
 	"fmt"
+	"html"
 	"os"
 	"strings"
 )
 
+// HTML is a trusted HTML fragment, added to summaries as is.
+// Text given to the Summary builder as a string is escaped, only convert content you control to HTML.
+type HTML string
+
+// escapeHTML returns text as an HTML fragment rendering it as is
+func escapeHTML(text string) HTML {
+	return HTML(html.EscapeString(text))
+}
+
+// htmlAttr is an attribute of an HTML element, rendered in the order given to wrap
+type htmlAttr struct {
+	name, value string
+}
+
 // SummaryTableCell represents a cell in a summary table.
 type SummaryTableCell struct {
-	// Data is the cell content.
+	// Data is the cell content, escaped.
 	Data string
+	// HTML is a trusted content rendered instead of Data when not empty, like a link.
+	HTML HTML
 	// Header renders cell as header (<th>) when true.
 	Header bool
 	// Colspan is the number of columns the cell extends (optional, default "1").
@@ -30,6 +48,13 @@ type SummaryImageOptions struct {
 type SummaryWriteOptions struct {
 	// Overwrite replaces all existing content when true (default: false, appends).
 	Overwrite bool
+	// Truncate lists the policies applied in order when the summary file would exceed MaxSize.
+	// Without policies, Write fails with ErrSummaryTooLarge and the buffer is kept.
+	Truncate []SummaryTruncation
+	// Spill writes the full buffer to a file under RUNNER_TEMP when it does not fit, see SummaryWriteResult.SpillPath.
+	Spill bool
+	// MaxSize is the maximum size of the summary file, SummaryMaxSize by default.
+	MaxSize int64
 }
 
 // Summary is a builder for GitHub Actions job summaries.
@@ -37,6 +62,12 @@ type SummaryWriteOptions struct {
 type Summary struct {
 	buffer   strings.Builder
 	filePath string
+	// section is the block of the file replaced by Write, see Section
+	section string
+	// ends are the offsets of the end-of-line markers in the buffer, delimiting its sections
+	ends []int
+	// err is the first invalid element added to the buffer, returned by Write
+	err error
 }
 
 // JobSummary is the package-level summary instance, equivalent to core.summary in the JS toolkit.
@@ -57,40 +88,87 @@ func (s *Summary) getFilePath() (string, error) {
 	return s.filePath, nil
 }
 
-func (s *Summary) wrap(tag, content string, attrs map[string]string) string {
+// wrap renders the element tag holding content. Attributes with an empty value are omitted, others are escaped.
+func (s *Summary) wrap(tag string, content HTML, attrs ...htmlAttr) HTML {
 	var attrStr strings.Builder
-	for k, v := range attrs {
-		fmt.Fprintf(&attrStr, ` %s="%s"`, k, v)
+	for _, a := range attrs {
+		if a.value != "" {
+			fmt.Fprintf(&attrStr, ` %s="%s"`, a.name, html.EscapeString(a.value))
+		}
 	}
 	if content == "" {
-		return fmt.Sprintf("<%s%s>", tag, attrStr.String())
+		return HTML(fmt.Sprintf("<%s%s>", tag, attrStr.String()))
+	}
+	return HTML(fmt.Sprintf("<%s%s>%s</%s>", tag, attrStr.String(), content, tag))
+}
+
+// fail records the first invalid element added to the buffer
+func (s *Summary) fail(err error) *Summary {
+	if s.err == nil {
+		s.err = err
 	}
-	return fmt.Sprintf("<%s%s>%s</%s>", tag, attrStr.String(), content, tag)
+	return s
+}
+
+// Err returns the first invalid element added to the buffer, like a heading level out of range, nil otherwise.
+// Write does not write a buffer holding an invalid element.
+func (s *Summary) Err() error {
+	return s.err
 }
 
 // Write flushes the buffer to the summary file and clears the buffer.
 // Appends by default; set options.Overwrite to replace existing content.
+// A summary returned by Section replaces its block of the file instead.
+// Secrets registered with SetSecret are masked.
+// See WriteWithResult to know whether the buffer was truncated to fit in the summary size limit.
 func (s *Summary) Write(options ...SummaryWriteOptions) error {
-	overwrite := len(options) > 0 && options[0].Overwrite
+	_, err := s.WriteWithResult(options...)
+	return err
+}
+
+// WriteWithResult writes the buffer like Write, truncating it with the policies of the options when the summary file
+// would exceed its maximum size, and returns what was written and dropped.
+func (s *Summary) WriteWithResult(options ...SummaryWriteOptions) (SummaryWriteResult, error) {
+	var opts SummaryWriteOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if s.err != nil {
+		return SummaryWriteResult{}, s.err
+	}
 	filePath, err := s.getFilePath()
 	if err != nil {
-		return err
+		return SummaryWriteResult{}, err
+	}
+	if s.section != "" {
+		return s.writeSection(filePath, opts)
+	}
+	var existing int64
+	if !opts.Overwrite {
+		if existing, err = s.FileSize(); err != nil {
+			return SummaryWriteResult{}, err
+		}
+	}
+	sections, result, err := s.fit(existing, opts)
+	if err != nil {
+		return result, err
 	}
 	flag := os.O_APPEND | os.O_WRONLY | os.O_CREATE
-	if overwrite {
+	if opts.Overwrite {
 		flag = os.O_TRUNC | os.O_WRONLY | os.O_CREATE
 	}
 	fd, err := os.OpenFile(filePath, flag, 0644)
 	if err != nil {
-		return err
+		return result, err
 	}
 	defer fd.Close()
-	_, err = fmt.Fprint(fd, s.buffer.String())
+	result.Written, err = fmt.Fprint(fd, strings.Join(sections, ""))
+	result.Size = existing + int64(result.Written)
 	if err != nil {
-		return err
+		return result, err
 	}
 	s.EmptyBuffer()
-	return nil
+	return result, nil
 }
 
 // Clear empties the buffer and wipes the summary file.
@@ -112,10 +190,12 @@ func (s *Summary) IsEmptyBuffer() bool {
 // EmptyBuffer resets the buffer without writing to the file.
 func (s *Summary) EmptyBuffer() *Summary {
 	s.buffer.Reset()
+	s.ends = nil
+	s.err = nil
 	return s
 }
 
-// AddRaw adds raw text to the buffer.
+// AddRaw adds raw text to the buffer, not escaped.
 func (s *Summary) AddRaw(text string, addEOL ...bool) *Summary {
 	s.buffer.WriteString(text)
 	if len(addEOL) > 0 && addEOL[0] {
@@ -126,18 +206,24 @@ func (s *Summary) AddRaw(text string, addEOL ...bool) *Summary {
 
 // AddEOL appends an OS-specific end-of-line marker to the buffer.
 func (s *Summary) AddEOL() *Summary {
-	return s.AddRaw(EOF)
+	s.AddRaw(EOF)
+	s.ends = append(s.ends, s.buffer.Len())
+	return s
+}
+
+// AddHTML adds a trusted HTML fragment to the buffer, followed by an end-of-line marker.
+func (s *Summary) AddHTML(fragment HTML) *Summary {
+	return s.AddRaw(string(fragment), true)
 }
 
 // AddCodeBlock adds an HTML code block to the buffer.
 // lang is an optional language for syntax highlighting.
 func (s *Summary) AddCodeBlock(code string, lang ...string) *Summary {
-	attrs := map[string]string{}
-	if len(lang) > 0 && lang[0] != "" {
-		attrs["lang"] = lang[0]
+	l := ""
+	if len(lang) > 0 {
+		l = lang[0]
 	}
-	element := s.wrap("pre", s.wrap("code", code, nil), attrs)
-	return s.AddRaw(element, true)
+	return s.AddHTML(s.wrap("pre", s.wrap("code", escapeHTML(code)), htmlAttr{"lang", l}))
 }
 
 // AddList adds an HTML list to the buffer.
@@ -149,10 +235,9 @@ func (s *Summary) AddList(items []string, ordered ...bool) *Summary {
 	}
 	var listItems strings.Builder
 	for _, item := range items {
-		listItems.WriteString(s.wrap("li", item, nil))
+		listItems.WriteString(string(s.wrap("li", escapeHTML(item))))
 	}
-	element := s.wrap(tag, listItems.String(), nil)
-	return s.AddRaw(element, true)
+	return s.AddHTML(s.wrap(tag, HTML(listItems.String())))
 }
 
 // AddTable adds an HTML table to the buffer.
@@ -166,75 +251,81 @@ func (s *Summary) AddTable(rows [][]SummaryTableCell) *Summary {
 			if cell.Header {
 				tag = "th"
 			}
-			attrs := map[string]string{}
-			if cell.Colspan != "" {
-				attrs["colspan"] = cell.Colspan
+			content := cell.HTML
+			if content == "" {
+				content = escapeHTML(cell.Data)
 			}
-			if cell.Rowspan != "" {
-				attrs["rowspan"] = cell.Rowspan
-			}
-			cells.WriteString(s.wrap(tag, cell.Data, attrs))
+			cells.WriteString(string(s.wrap(tag, content, htmlAttr{"colspan", cell.Colspan}, htmlAttr{"rowspan", cell.Rowspan})))
 		}
-		tableBody.WriteString(s.wrap("tr", cells.String(), nil))
+		tableBody.WriteString(string(s.wrap("tr", HTML(cells.String()))))
 	}
-	element := s.wrap("table", tableBody.String(), nil)
-	return s.AddRaw(element, true)
+	return s.AddHTML(s.wrap("table", HTML(tableBody.String())))
 }
 
-// AddDetails adds a collapsible HTML details element.
+// AddDetails adds a collapsible HTML details element, labelled with label and holding the text content.
 func (s *Summary) AddDetails(label, content string) *Summary {
-	element := s.wrap("details", s.wrap("summary", label, nil)+content, nil)
-	return s.AddRaw(element, true)
+	return s.AddDetailsHTML(label, escapeHTML(content))
+}
+
+// AddDetailsHTML adds a collapsible HTML details element, labelled with label and holding the trusted content.
+func (s *Summary) AddDetailsHTML(label string, content HTML) *Summary {
+	return s.AddHTML(s.wrap("details", s.wrap("summary", escapeHTML(label))+content))
 }
 
 // AddImage adds an HTML image tag.
 func (s *Summary) AddImage(src, alt string, options ...SummaryImageOptions) *Summary {
-	attrs := map[string]string{"src": src, "alt": alt}
+	var opts SummaryImageOptions
 	if len(options) > 0 {
-		if options[0].Width != "" {
-			attrs["width"] = options[0].Width
-		}
-		if options[0].Height != "" {
-			attrs["height"] = options[0].Height
-		}
+		opts = options[0]
 	}
-	element := s.wrap("img", "", attrs)
-	return s.AddRaw(element, true)
+	return s.AddHTML(s.wrap("img", "",
+		htmlAttr{"src", src}, htmlAttr{"alt", alt}, htmlAttr{"width", opts.Width}, htmlAttr{"height", opts.Height}))
+}
+
+// ErrInvalidHeadingLevel is returned by Write when a heading level is not between 1 and 6
+var ErrInvalidHeadingLevel = errors.New("invalid heading level, must be between 1 and 6")
+
+// headingLevel returns the heading level from the optional argument, 1 by default
+func headingLevel(level []int) (int, error) {
+	if len(level) == 0 {
+		return 1, nil
+	}
+	if level[0] < 1 || level[0] > 6 {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidHeadingLevel, level[0])
+	}
+	return level[0], nil
 }
 
 // AddHeading adds an HTML heading element (h1–h6). Level defaults to 1.
+// A level out of range is not added and makes Write fail with ErrInvalidHeadingLevel.
 func (s *Summary) AddHeading(text string, level ...int) *Summary {
-	lvl := 1
-	if len(level) > 0 && level[0] >= 1 && level[0] <= 6 {
-		lvl = level[0]
+	lvl, err := headingLevel(level)
+	if err != nil {
+		return s.fail(err)
 	}
-	tag := fmt.Sprintf("h%d", lvl)
-	element := s.wrap(tag, text, nil)
-	return s.AddRaw(element, true)
+	return s.AddHTML(s.wrap(fmt.Sprintf("h%d", lvl), escapeHTML(text)))
 }
 
 // AddSeparator adds an HTML thematic break (<hr>).
 func (s *Summary) AddSeparator() *Summary {
-	return s.AddRaw(s.wrap("hr", "", nil), true)
+	return s.AddHTML(s.wrap("hr", ""))
 }
 
 // AddBreak adds an HTML line break (<br>).
 func (s *Summary) AddBreak() *Summary {
-	return s.AddRaw(s.wrap("br", "", nil), true)
+	return s.AddHTML(s.wrap("br", ""))
 }
 
 // AddQuote adds an HTML blockquote. cite is an optional citation URL.
 func (s *Summary) AddQuote(text string, cite ...string) *Summary {
-	attrs := map[string]string{}
-	if len(cite) > 0 && cite[0] != "" {
-		attrs["cite"] = cite[0]
+	c := ""
+	if len(cite) > 0 {
+		c = cite[0]
 	}
-	element := s.wrap("blockquote", text, attrs)
-	return s.AddRaw(element, true)
+	return s.AddHTML(s.wrap("blockquote", escapeHTML(text), htmlAttr{"cite", c}))
 }
 
 // AddLink adds an HTML anchor tag.
 func (s *Summary) AddLink(text, href string) *Summary {
-	element := s.wrap("a", text, map[string]string{"href": href})
-	return s.AddRaw(element, true)
+	return s.AddHTML(s.wrap("a", escapeHTML(text), htmlAttr{"href", href}))
 }
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SummaryMaxSize is the maximum size of the summary file of a step, GitHub does not display larger summaries
+const SummaryMaxSize = 1024 * 1024
+
+// ErrSummaryTooLarge is returned by Write when the summary file would exceed its maximum size
+var ErrSummaryTooLarge = errors.New("job summary too large")
+
+// SummaryTruncation is a policy reducing the size of the buffer, to fit in the summary size limit
+type SummaryTruncation int
+
+const (
+	// TruncateDropOldest drops the first sections of the buffer, a section being an element added to the summary
+	TruncateDropOldest SummaryTruncation = iota + 1
+	// TruncateCollapseDetails removes the content of the details elements, keeping their label
+	TruncateCollapseDetails
+	// TruncateOmitRows removes the last rows of the largest tables, followed by a "N more rows omitted" paragraph
+	TruncateOmitRows
+)
+
+// SummaryWriteResult describes what Summary.WriteWithResult wrote
+type SummaryWriteResult struct {
+	// Size is the size of the summary file after the write
+	Size int64
+	// Written is the number of bytes written
+	Written int
+	// DroppedSections is the number of sections dropped by TruncateDropOldest
+	DroppedSections int
+	// CollapsedDetails is the number of details elements emptied by TruncateCollapseDetails
+	CollapsedDetails int
+	// OmittedRows is the number of table rows removed by TruncateOmitRows
+	OmittedRows int
+	// SpillPath is the file holding the full buffer when it did not fit and SummaryWriteOptions.Spill is set,
+	// typically to upload as an artifact
+	SpillPath string
+}
+
+// Truncated reports whether the buffer was truncated to fit in the summary size limit
+func (r SummaryWriteResult) Truncated() bool {
+	return r.DroppedSections > 0 || r.CollapsedDetails > 0 || r.OmittedRows > 0
+}
+
+// FileSize returns the current size of the summary file
+func (s *Summary) FileSize() (int64, error) {
+	filePath, err := s.getFilePath()
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// sections splits the buffer at its end-of-line markers, blank lines are kept with the previous section
+func (s *Summary) sections() []string {
+	content := s.buffer.String()
+	var sections []string
+	start := 0
+	for _, end := range append(s.ends, len(content)) {
+		if end <= start {
+			continue
+		}
+		section := content[start:end]
+		if len(sections) > 0 && strings.TrimSpace(section) == "" {
+			sections[len(sections)-1] += section
+		} else {
+			sections = append(sections, section)
+		}
+		start = end
+	}
+	return sections
+}
+
+// fit returns the masked sections of the buffer fitting in the summary file already holding existing bytes.
+// The section markers found in the buffer of a section are escaped, see escapeSectionMarkers
+func (s *Summary) fit(existing int64, opts SummaryWriteOptions) ([]string, SummaryWriteResult, error) {
+	var result SummaryWriteResult
+	sections := s.sections()
+	for i := range sections {
+		sections[i] = MaskSecrets(sections[i])
+		if s.section != "" {
+			sections[i] = escapeSectionMarkers(sections[i])
+		}
+	}
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = SummaryMaxSize
+	}
+	available := int(maxSize - existing)
+	size := sectionsSize(sections)
+	if size <= available {
+		return sections, result, nil
+	}
+	if opts.Spill {
+		path, err := spillSummary(strings.Join(sections, ""))
+		if err != nil {
+			return nil, result, err
+		}
+		result.SpillPath = path
+	}
+	for _, policy := range opts.Truncate {
+		var n int
+		switch policy {
+		case TruncateDropOldest:
+			sections, n = dropOldestSections(sections, available)
+			result.DroppedSections += n
+		case TruncateCollapseDetails:
+			sections, n = collapseDetails(sections, available)
+			result.CollapsedDetails += n
+		case TruncateOmitRows:
+			sections, n = omitRows(sections, available)
+			result.OmittedRows += n
+		default:
+			return nil, result, fmt.Errorf("unknown summary truncation policy %d", policy)
+		}
+		if size = sectionsSize(sections); size <= available {
+			return sections, result, nil
+		}
+	}
+	return nil, result, fmt.Errorf("%w: %d bytes to write, %d available out of %d", ErrSummaryTooLarge, size, available, maxSize)
+}
+
+func sectionsSize(sections []string) int {
+	size := 0
+	for _, section := range sections {
+		size += len(section)
+	}
+	return size
+}
+
+// spillSummary writes content to a new file under RUNNER_TEMP and returns its path
+func spillSummary(content string) (string, error) {
+	dir := os.Getenv("RUNNER_TEMP")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	fd, err := os.CreateTemp(dir, "step-summary-*.md")
+	if err != nil {
+		return "", fmt.Errorf("unable to create the full summary file: %w", err)
+	}
+	_, err = fd.WriteString(content)
+	if closeErr := fd.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fd.Name())
+		return "", fmt.Errorf("unable to write the full summary file: %w", err)
+	}
+	return fd.Name(), nil
+}
+
+func dropOldestSections(sections []string, available int) ([]string, int) {
+	size := sectionsSize(sections)
+	dropped := 0
+	for dropped < len(sections) && size > available {
+		size -= len(sections[dropped])
+		dropped++
+	}
+	return sections[dropped:], dropped
+}
+
+// collapseDetails empties the details elements, from the first one, until the sections fit
+func collapseDetails(sections []string, available int) ([]string, int) {
+	size := sectionsSize(sections)
+	collapsed := 0
+	for i, section := range sections {
+		if size <= available {
+			break
+		}
+		end := strings.Index(section, "</summary>")
+		body := strings.TrimRight(section, "\r\n")
+		if !strings.HasPrefix(section, "<details>") || !strings.HasSuffix(body, "</details>") || end < 0 {
+			continue
+		}
+		short := section[:end+len("</summary>")] + "<p>Content omitted</p></details>" + section[len(body):]
+		if len(short) < len(section) {
+			size -= len(section) - len(short)
+			sections[i] = short
+			collapsed++
+		}
+	}
+	return sections, collapsed
+}
+
+// omitRows removes the last rows of the tables, from the largest one, until the sections fit.
+// The first row of each table, usually holding the headers, is kept.
+func omitRows(sections []string, available int) ([]string, int) {
+	var tables []int
+	for i, section := range sections {
+		if strings.HasPrefix(section, "<table>") && strings.Count(section, "<table") == 1 {
+			tables = append(tables, i)
+		}
+	}
+	sort.SliceStable(tables, func(a, b int) bool { return len(sections[tables[a]]) > len(sections[tables[b]]) })
+	size := sectionsSize(sections)
+	omitted := 0
+	for _, i := range tables {
+		if size <= available {
+			break
+		}
+		section := sections[i]
+		end := strings.LastIndex(section, "</table>")
+		if end < 0 {
+			continue
+		}
+		rows := strings.SplitAfter(section[len("<table>"):end], "</tr>")
+		if rows[len(rows)-1] == "" {
+			rows = rows[:len(rows)-1]
+		}
+		note := func(kept int) string {
+			return fmt.Sprintf("<p>%d more rows omitted</p>", len(rows)-kept)
+		}
+		kept, removed := len(rows), 0
+		for kept > 1 && size-removed+len(note(kept)) > available {
+			kept--
+			removed += len(rows[kept])
+		}
+		short := "<table>" + strings.Join(rows[:kept], "") + "</table>" + note(kept) + section[end+len("</table>"):]
+		if kept < len(rows) && len(short) < len(section) {
+			size -= len(section) - len(short)
+			sections[i] = short
+			omitted += len(rows) - kept
+		}
+	}
+	return sections, omitted
+}
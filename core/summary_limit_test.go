@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryFileSize(t *testing.T) {
+	path, s := withSummaryFile(t)
+	require.NoError(t, os.WriteFile(path, []byte("previous step"), 0644))
+	size, err := s.FileSize()
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), size)
+
+	result, err := s.AddRaw("content").WriteWithResult()
+	require.NoError(t, err)
+	assert.Equal(t, SummaryWriteResult{Size: 20, Written: 7}, result)
+	assert.False(t, result.Truncated())
+}
+
+func TestSummaryTooLarge(t *testing.T) {
+	path, s := withSummaryFile(t)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", 60)), 0644))
+	s.AddRaw(strings.Repeat("y", 50), true)
+	_, err := s.WriteWithResult(SummaryWriteOptions{MaxSize: 100})
+	assert.ErrorIs(t, err, ErrSummaryTooLarge)
+	assert.False(t, s.IsEmptyBuffer())
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, content, 60)
+
+	result, err := s.WriteWithResult(SummaryWriteOptions{MaxSize: 100, Overwrite: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(50+len(EOF)), result.Size)
+}
+
+func TestSummaryTruncateDropOldest(t *testing.T) {
+	path, s := withSummaryFile(t)
+	s.AddHeading("first").AddEOL().AddHeading("second").AddRaw("third", true)
+	result, err := s.WriteWithResult(SummaryWriteOptions{MaxSize: 30, Truncate: []SummaryTruncation{TruncateDropOldest}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.DroppedSections)
+	assert.True(t, result.Truncated())
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>second</h1>"+EOF+"third"+EOF, string(content))
+}
+
+func TestSummaryTruncateCollapseDetails(t *testing.T) {
+	path, s := withSummaryFile(t)
+	s.AddDetailsHTML("logs", HTML(strings.Repeat("x", 200))).AddDetails("small", "y")
+	result, err := s.WriteWithResult(SummaryWriteOptions{MaxSize: 150, Truncate: []SummaryTruncation{TruncateCollapseDetails}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.CollapsedDetails)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<details><summary>logs</summary><p>Content omitted</p></details>"+EOF+
+		"<details><summary>small</summary>y</details>"+EOF, string(content))
+}
+
+func TestSummaryTruncateOmitRows(t *testing.T) {
+	path, s := withSummaryFile(t)
+	rows := [][]SummaryTableCell{{{Data: "Test", Header: true}}}
+	for i := 0; i < 100; i++ {
+		rows = append(rows, []SummaryTableCell{{Data: "test"}})
+	}
+	s.AddHeading("Tests").AddTable(rows)
+	result, err := s.WriteWithResult(SummaryWriteOptions{MaxSize: 200, Truncate: []SummaryTruncation{TruncateOmitRows}})
+	require.NoError(t, err)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(content), 200)
+	assert.Equal(t, int64(len(content)), result.Size)
+	kept := strings.Count(string(content), "<td>test</td>")
+	assert.Equal(t, 100, kept+result.OmittedRows)
+	assert.Greater(t, kept, 0)
+	assert.Contains(t, string(content), "<table><tr><th>Test</th></tr><tr><td>test</td></tr>")
+	assert.True(t, strings.HasSuffix(string(content), fmt.Sprintf("</table><p>%d more rows omitted</p>%s", result.OmittedRows, EOF)))
+}
+
+func TestSummaryTruncateSpill(t *testing.T) {
+	_, s := withSummaryFile(t)
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+	withSecrets(t, "s3cr3t")
+	s.AddRaw("s3cr3t", true).AddDetailsHTML("logs", HTML(strings.Repeat("x", 100)))
+	result, err := s.WriteWithResult(SummaryWriteOptions{MaxSize: 50, Spill: true, Truncate: []SummaryTruncation{TruncateCollapseDetails}})
+	assert.ErrorIs(t, err, ErrSummaryTooLarge)
+	require.NotEmpty(t, result.SpillPath)
+	assert.True(t, strings.HasPrefix(result.SpillPath, os.Getenv("RUNNER_TEMP")))
+	content, err := os.ReadFile(result.SpillPath)
+	require.NoError(t, err)
+	assert.Equal(t, "***"+EOF+"<details><summary>logs</summary>"+strings.Repeat("x", 100)+"</details>"+EOF, string(content))
+
+	result, err = s.WriteWithResult(SummaryWriteOptions{MaxSize: 100, Truncate: []SummaryTruncation{TruncateCollapseDetails, TruncateDropOldest}})
+	require.NoError(t, err)
+	assert.Equal(t, SummaryWriteResult{Size: int64(67 + 2*len(EOF)), Written: 67 + 2*len(EOF), CollapsedDetails: 1}, result)
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	summarySectionStart = "<!-- actions-go-section:start %s -->"
+	summarySectionEnd   = "<!-- actions-go-section:end %s -->"
+)
+
+// summarySectionMarkers escapes the section markers, see escapeSectionMarkers
+var summarySectionMarkers = strings.NewReplacer(
+	"<!-- actions-go-section:start", "&lt;!-- actions-go-section:start",
+	"<!-- actions-go-section:end", "&lt;!-- actions-go-section:end",
+)
+
+var (
+	summarySectionID     = regexp.MustCompile(`^[A-Za-z0-9_.:]+(-[A-Za-z0-9_.:]+)*$`)
+	summarySectionStarts = regexp.MustCompile(`(?m)^<!-- actions-go-section:start (\S+) -->(\r?\n)?`)
+)
+
+// SummarySection is a part of the summary file, as read by Summary.ReadSections
+type SummarySection struct {
+	// ID is the name given to Summary.Section, empty for the content written outside of named sections
+	ID string
+	// Content is the content of the section, without its markers
+	Content string
+
+	// start and end are the offsets of the section in the file, markers included
+	start, end int
+}
+
+// Section returns a summary builder whose Write replaces the block named id in the summary file,
+// or appends it when missing, leaving the rest of the file untouched.
+// Blocks are delimited by hidden HTML comments, ids are made of letters, digits, and `_.:-` characters.
+//
+//	core.JobSummary.Section("progress").AddTable(rows).Write()
+func (s *Summary) Section(id string) *Summary {
+	section := &Summary{filePath: s.filePath, section: id}
+	if !summarySectionID.MatchString(id) {
+		section.fail(fmt.Errorf("invalid summary section id %q", id))
+	}
+	return section
+}
+
+// ReadSections parses the summary file into the named sections written with Section and the content around them
+func (s *Summary) ReadSections() ([]SummarySection, error) {
+	filePath, err := s.getFilePath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseSummarySections(string(content)), nil
+}
+
+// RemoveSection removes the block named id from the summary file, if any
+func (s *Summary) RemoveSection(id string) error {
+	filePath, err := s.getFilePath()
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	section, ok := findSummarySection(string(content), id)
+	if !ok {
+		return nil
+	}
+	return os.WriteFile(filePath, append(content[:section.start:section.start], content[section.end:]...), 0644)
+}
+
+// writeSection replaces the block of the section in the summary file with the buffer
+func (s *Summary) writeSection(filePath string, opts SummaryWriteOptions) (SummaryWriteResult, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return SummaryWriteResult{}, err
+	}
+	file := string(content)
+	start, end := len(file), len(file)
+	prefix := ""
+	if section, ok := findSummarySection(file, s.section); ok {
+		start, end = section.start, section.end
+	} else if file != "" && !strings.HasSuffix(file, "\n") {
+		prefix = EOF
+	}
+	startMarker := prefix + fmt.Sprintf(summarySectionStart, s.section) + EOF
+	endMarker := fmt.Sprintf(summarySectionEnd, s.section) + EOF
+	existing := int64(len(file)-(end-start)) + int64(len(startMarker)+len(endMarker))
+	sections, result, err := s.fit(existing, opts)
+	if err != nil {
+		return result, err
+	}
+	body := strings.Join(sections, "")
+	if body != "" && !strings.HasSuffix(body, "\n") {
+		body += EOF
+	}
+	block := startMarker + body + endMarker
+	if err := os.WriteFile(filePath, []byte(file[:start]+block+file[end:]), 0644); err != nil {
+		return result, err
+	}
+	result.Written = len(block)
+	result.Size = int64(len(file) - (end - start) + len(block))
+	s.EmptyBuffer()
+	return result, nil
+}
+
+func findSummarySection(content, id string) (SummarySection, bool) {
+	for _, section := range parseSummarySections(content) {
+		if section.ID == id {
+			return section, true
+		}
+	}
+	return SummarySection{}, false
+}
+
+// parseSummarySections splits content into its named sections and the content around them.
+// A start marker without its end marker is left in the surrounding content.
+func parseSummarySections(content string) []SummarySection {
+	var sections []SummarySection
+	text := func(start, end int) {
+		if start < end {
+			sections = append(sections, SummarySection{Content: content[start:end], start: start, end: end})
+		}
+	}
+	pos := 0
+	for search := 0; search < len(content); {
+		match := summarySectionStarts.FindStringSubmatchIndex(content[search:])
+		if match == nil {
+			break
+		}
+		start, bodyStart := search+match[0], search+match[1]
+		id := content[search+match[2] : search+match[3]]
+		endMarker := fmt.Sprintf(summarySectionEnd, id)
+		bodyEnd := strings.Index(content[bodyStart:], endMarker)
+		if bodyEnd < 0 {
+			search = bodyStart
+			continue
+		}
+		bodyEnd += bodyStart
+		end := bodyEnd + len(endMarker)
+		if strings.HasPrefix(content[end:], "\r\n") {
+			end += 2
+		} else if strings.HasPrefix(content[end:], "\n") {
+			end++
+		}
+		text(pos, start)
+		sections = append(sections, SummarySection{ID: id, Content: content[bodyStart:bodyEnd], start: start, end: end})
+		pos, search = end, end
+	}
+	text(pos, len(content))
+	return sections
+}
+
+// escapeSectionMarkers escapes the section markers found in the content of a section,
+// which would otherwise end its block early or start a new one when the summary file is parsed
+func escapeSectionMarkers(content string) string {
+	return summarySectionMarkers.Replace(content)
+}
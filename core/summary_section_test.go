@@ -0,0 +1,103 @@
+package core
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarySection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	path, s := withSummaryFile(t)
+	require.NoError(t, s.AddHeading("Build").Write())
+	require.NoError(t, s.Section("progress").AddRaw("1 of 3").Write())
+	require.NoError(t, s.AddRaw("appended", true).Write())
+	require.NoError(t, s.Section("results").AddList([]string{"ok"}).Write())
+
+	result, err := s.Section("progress").AddRaw("3 of 3", true).WriteWithResult()
+	require.NoError(t, err)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>Build</h1>\n"+
+		"<!-- actions-go-section:start progress -->\n3 of 3\n<!-- actions-go-section:end progress -->\n"+
+		"appended\n"+
+		"<!-- actions-go-section:start results -->\n<ul><li>ok</li></ul>\n<!-- actions-go-section:end results -->\n", string(content))
+	assert.Equal(t, int64(len(content)), result.Size)
+
+	sections, err := s.ReadSections()
+	require.NoError(t, err)
+	ids := []string{}
+	contents := []string{}
+	for _, section := range sections {
+		ids = append(ids, section.ID)
+		contents = append(contents, section.Content)
+	}
+	assert.Equal(t, []string{"", "progress", "", "results"}, ids)
+	assert.Equal(t, []string{"<h1>Build</h1>\n", "3 of 3\n", "appended\n", "<ul><li>ok</li></ul>\n"}, contents)
+
+	require.NoError(t, s.RemoveSection("progress"))
+	require.NoError(t, s.RemoveSection("missing"))
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>Build</h1>\nappended\n"+
+		"<!-- actions-go-section:start results -->\n<ul><li>ok</li></ul>\n<!-- actions-go-section:end results -->\n", string(content))
+}
+
+func TestSummarySectionEscapesMarkers(t *testing.T) {
+	path, s := withSummaryFile(t)
+	body := "<!-- actions-go-section:end progress -->\n<!-- actions-go-section:start other -->\n"
+	require.NoError(t, s.Section("progress").AddRaw(body).Write())
+	require.NoError(t, s.Section("progress").AddRaw("replaced").Write())
+	require.NoError(t, s.Section("other").AddRaw("other").Write())
+
+	sections, err := s.ReadSections()
+	require.NoError(t, err)
+	require.Len(t, sections, 2)
+	assert.Equal(t, SummarySection{ID: "progress", Content: "replaced\n"}, SummarySection{ID: sections[0].ID, Content: sections[0].Content})
+	assert.Equal(t, SummarySection{ID: "other", Content: "other\n"}, SummarySection{ID: sections[1].ID, Content: sections[1].Content})
+
+	require.NoError(t, s.Section("progress").AddRaw(body).Write())
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "&lt;!-- actions-go-section:end progress -->\n&lt;!-- actions-go-section:start other -->\n")
+	sections, err = s.ReadSections()
+	require.NoError(t, err)
+	require.Len(t, sections, 2)
+	assert.Equal(t, "&lt;!-- actions-go-section:end progress -->\n&lt;!-- actions-go-section:start other -->\n", sections[0].Content)
+	assert.Equal(t, "other\n", sections[1].Content)
+}
+
+func TestSummarySectionInvalidID(t *testing.T) {
+	path, s := withSummaryFile(t)
+	for _, id := range []string{"", "a b", "a--b", "-->", "x\ny"} {
+		assert.Error(t, s.Section(id).AddRaw("content").Write(), id)
+	}
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+}
+
+func TestParseSummarySections(t *testing.T) {
+	assert.Equal(t, []SummarySection{
+		{Content: "before\n<!-- actions-go-section:start open -->\nno end\n", start: 0, end: 53},
+	}, parseSummarySections("before\n<!-- actions-go-section:start open -->\nno end\n"))
+	assert.Equal(t, []SummarySection{
+		{ID: "a", Content: "", start: 0, end: 69},
+	}, parseSummarySections("<!-- actions-go-section:start a -->\n<!-- actions-go-section:end a -->"))
+}
+
+func TestSummarySectionLimit(t *testing.T) {
+	_, s := withSummaryFile(t)
+	require.NoError(t, s.Section("big").AddRaw("small").Write())
+	_, err := s.Section("big").AddRaw("this content does not fit").WriteWithResult(SummaryWriteOptions{MaxSize: 80})
+	assert.ErrorIs(t, err, ErrSummaryTooLarge)
+	sections, err := s.ReadSections()
+	require.NoError(t, err)
+	require.Len(t, sections, 1)
+	assert.Equal(t, "small"+EOF, sections[0].Content)
+}
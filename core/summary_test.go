@@ -174,3 +174,49 @@ func TestSummaryNoEnvVar(t *testing.T) {
 	err := s.Write()
 	assert.Error(t, err)
 }
+
+func TestSummaryEscapesText(t *testing.T) {
+	_, s := withSummaryFile(t)
+	s.AddHeading(`feature/<script>"x"`, 2).
+		AddList([]string{"a < b", "</ul>"}).
+		AddTable([][]SummaryTableCell{
+			{{Data: "</table>", Header: true}, {Data: "Link", Header: true}},
+			{{Data: "a & b", Colspan: `2" onclick="x`}, {HTML: `<a href="https://example.com">ok</a>`}},
+		}).
+		AddDetailsHTML("<b>label</b>", HTML("<p>trusted</p>")).
+		AddDetails("text", "<p>escaped</p>").
+		AddCodeBlock("if a < b && c {}", `go"`).
+		AddQuote("<i>quote</i>", "https://example.com/?a=1&b=2").
+		AddLink("<click>", `https://example.com/"onmouseover`)
+	assert.Equal(t, "<h2>feature/&lt;script&gt;&#34;x&#34;</h2>"+EOF+
+		"<ul><li>a &lt; b</li><li>&lt;/ul&gt;</li></ul>"+EOF+
+		`<table><tr><th>&lt;/table&gt;</th><th>Link</th></tr><tr><td colspan="2&#34; onclick=&#34;x">a &amp; b</td><td><a href="https://example.com">ok</a></td></tr></table>`+EOF+
+		"<details><summary>&lt;b&gt;label&lt;/b&gt;</summary><p>trusted</p></details>"+EOF+
+		"<details><summary>text</summary>&lt;p&gt;escaped&lt;/p&gt;</details>"+EOF+
+		`<pre lang="go&#34;"><code>if a &lt; b &amp;&amp; c {}</code></pre>`+EOF+
+		`<blockquote cite="https://example.com/?a=1&amp;b=2">&lt;i&gt;quote&lt;/i&gt;</blockquote>`+EOF+
+		`<a href="https://example.com/&#34;onmouseover">&lt;click&gt;</a>`+EOF, s.Stringify())
+}
+
+func TestSummaryAttributesOrder(t *testing.T) {
+	_, s := withSummaryFile(t)
+	for i := 0; i < 20; i++ {
+		s.EmptyBuffer().AddImage("img.png", "alt", SummaryImageOptions{Width: "100", Height: "200"})
+		assert.Equal(t, `<img src="img.png" alt="alt" width="100" height="200">`+EOF, s.Stringify())
+	}
+}
+
+func TestSummaryInvalidHeadingLevel(t *testing.T) {
+	path, s := withSummaryFile(t)
+	s.AddHeading("Title", 7).AddRaw("content")
+	assert.Equal(t, "content", s.Stringify())
+	assert.ErrorIs(t, s.Err(), ErrInvalidHeadingLevel)
+	assert.ErrorIs(t, s.Write(), ErrInvalidHeadingLevel)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+
+	s.EmptyBuffer().AddMarkdown(NewMarkdown().Heading("Title", 0))
+	assert.EqualError(t, s.Write(), "invalid heading level, must be between 1 and 6: 0")
+	assert.NoError(t, s.EmptyBuffer().AddHeading("Title", 6).Write())
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TasksOptions controls how Tasks runs and renders concurrent tasks
+type TasksOptions struct {
+	// Live writes the task messages as soon as they are logged, each line prefixed with the task name,
+	// instead of buffering them into a group written when the task completes
+	Live bool
+	// Limit is the maximum number of tasks running at once, 0 meaning no limit
+	Limit int
+}
+
+// TaskResult describes the outcome of a task
+type TaskResult struct {
+	Name    string
+	Err     error
+	Elapsed time.Duration
+}
+
+// TasksError is returned by Tasks.Wait when at least one task failed
+type TasksError struct {
+	// Total is the number of tasks run
+	Total int
+	// Failed holds the results of the failed tasks, in the order they were started
+	Failed []TaskResult
+}
+
+func (e *TasksError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for _, r := range e.Failed {
+		names = append(names, r.Name)
+	}
+	return fmt.Sprintf("%d of %d tasks failed: %s", len(e.Failed), e.Total, strings.Join(names, ", "))
+}
+
+// Unwrap returns the errors of the failed tasks
+func (e *TasksError) Unwrap() []error {
+	r := make([]error, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		r = append(r, f.Err)
+	}
+	return r
+}
+
+// Tasks runs functions concurrently without interleaving their output.
+//
+// Each task logs through its own TaskLogger. Unless TasksOptions.Live is set, the output of a task is buffered
+// and written as a single group once the task completes, titled with the task name, its elapsed time and
+// a failure marker when it fails.
+type Tasks struct {
+	ctx     context.Context
+	opts    TasksOptions
+	start   time.Time
+	wg      sync.WaitGroup
+	sem     chan struct{}
+	mu      sync.Mutex
+	results []TaskResult
+}
+
+// NewTasks creates a Tasks whose tasks receive ctx
+func NewTasks(ctx context.Context, options ...TasksOptions) *Tasks {
+	opts := TasksOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	t := &Tasks{ctx: ctx, opts: opts, start: now()}
+	if opts.Limit > 0 {
+		t.sem = make(chan struct{}, opts.Limit)
+	}
+	return t
+}
+
+// Go starts f in a new goroutine. A panic in f is recovered and reported as the task error.
+func (t *Tasks) Go(name string, f func(ctx context.Context, log *TaskLogger) error) {
+	t.mu.Lock()
+	index := len(t.results)
+	t.results = append(t.results, TaskResult{Name: name})
+	t.mu.Unlock()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		if t.sem != nil {
+			t.sem <- struct{}{}
+			defer func() { <-t.sem }()
+		}
+		log := &TaskLogger{name: name, live: t.opts.Live}
+		start := now()
+		err := runTask(t.ctx, log, f)
+		result := TaskResult{Name: name, Err: err, Elapsed: now().Sub(start).Round(time.Millisecond)}
+		log.finish(result)
+		t.mu.Lock()
+		t.results[index] = result
+		t.mu.Unlock()
+	}()
+}
+
+func runTask(ctx context.Context, log *TaskLogger, f func(ctx context.Context, log *TaskLogger) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return f(ctx, log)
+}
+
+// Wait waits for all tasks to complete, writes a status line aggregating their results and returns
+// a *TasksError when any of them failed
+func (t *Tasks) Wait() error {
+	t.wg.Wait()
+	results := t.Results()
+	elapsed := now().Sub(t.start).Round(time.Millisecond)
+	var failed []TaskResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) == 0 {
+		Infof("%s %d tasks completed in %s", GroupSuccessMarker, len(results), elapsed)
+		return nil
+	}
+	err := &TasksError{Total: len(results), Failed: failed}
+	Infof("%s %s after %s", GroupFailureMarker, err.Error(), elapsed)
+	return err
+}
+
+// Results returns the results of the tasks, in the order they were started.
+// The results of tasks still running only hold their name.
+func (t *Tasks) Results() []TaskResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TaskResult(nil), t.results...)
+}
+
+// TaskLogger writes the messages of a single task, see Tasks
+type TaskLogger struct {
+	name   string
+	live   bool
+	mu     sync.Mutex
+	events []Event
+}
+
+// Name returns the name of the task
+func (l *TaskLogger) Name() string {
+	return l.name
+}
+
+// IssueCommand writes a workflow command in the task output.
+// In live mode, the message is prefixed with the task name.
+func (l *TaskLogger) IssueCommand(kind string, properties map[string]string, message string) {
+	if l.live {
+		message = l.prefix() + message
+	}
+	l.emit(Event{Kind: kind, Properties: properties, Message: message})
+}
+
+// Info writes the message in the task output
+func (l *TaskLogger) Info(message string) {
+	if l.live {
+		message = l.prefix() + strings.ReplaceAll(message, "\n", "\n"+l.prefix())
+	}
+	l.emit(Event{Message: message})
+}
+
+// Infof writes a formatted message in the task output
+func (l *TaskLogger) Infof(format string, args ...interface{}) {
+	l.Info(fmt.Sprintf(format, args...))
+}
+
+// Debug writes a debug message in the task output
+func (l *TaskLogger) Debug(message string) {
+	l.IssueCommand("debug", nil, message)
+}
+
+// Debugf writes a formatted debug message in the task output
+func (l *TaskLogger) Debugf(format string, args ...interface{}) {
+	l.Debug(fmt.Sprintf(format, args...))
+}
+
+// Error adds an error issue with optional annotation properties
+func (l *TaskLogger) Error(message string, properties ...AnnotationProperties) {
+	l.annotate("error", message, properties)
+}
+
+// Errorf adds a formatted error issue
+func (l *TaskLogger) Errorf(format string, args ...interface{}) {
+	l.Error(fmt.Sprintf(format, args...))
+}
+
+// Warning adds a warning issue with optional annotation properties
+func (l *TaskLogger) Warning(message string, properties ...AnnotationProperties) {
+	l.annotate("warning", message, properties)
+}
+
+// Warningf adds a formatted warning issue
+func (l *TaskLogger) Warningf(format string, args ...interface{}) {
+	l.Warning(fmt.Sprintf(format, args...))
+}
+
+// Notice adds a notice issue with optional annotation properties
+func (l *TaskLogger) Notice(message string, properties ...AnnotationProperties) {
+	l.annotate("notice", message, properties)
+}
+
+// Noticef adds a formatted notice issue
+func (l *TaskLogger) Noticef(format string, args ...interface{}) {
+	l.Notice(fmt.Sprintf(format, args...))
+}
+
+func (l *TaskLogger) annotate(kind, message string, properties []AnnotationProperties) {
+	var props map[string]string
+	if len(properties) > 0 {
+		props = annotationToProperties(properties[0])
+	}
+	l.IssueCommand(kind, props, message)
+}
+
+func (l *TaskLogger) prefix() string {
+	return "[" + l.name + "] "
+}
+
+func (l *TaskLogger) emit(e Event) {
+	if l.live {
+		Emit(e)
+		return
+	}
+	l.mu.Lock()
+	l.events = append(l.events, e)
+	l.mu.Unlock()
+}
+
+// finish writes the status line of a live task or the whole group of a buffered one.
+// Failures are reported as error annotations so they are listed in the run summary.
+func (l *TaskLogger) finish(r TaskResult) {
+	if l.live {
+		if r.Err != nil {
+			l.Errorf("%s failed after %s: %v", GroupFailureMarker, r.Elapsed, r.Err)
+			return
+		}
+		l.Infof("%s completed in %s", GroupSuccessMarker, r.Elapsed)
+		return
+	}
+	title := fmt.Sprintf("%s (%s)", r.Name, r.Elapsed)
+	if r.Err != nil {
+		title = GroupFailureMarker + " " + title
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events := append([]Event{{Kind: "group", Message: title}}, l.events...)
+	if r.Err != nil {
+		events = append(events, Event{Kind: "error", Message: fmt.Sprintf("%s: %v", r.Name, r.Err)})
+	}
+	Emit(append(events, Event{Kind: "endgroup"})...)
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTasksBuffered(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	b := withInputs(t, nil)
+	withClock(t, time.Second)
+
+	release := make(chan struct{})
+	tasks := NewTasks(context.Background())
+	tasks.Go("first", func(ctx context.Context, log *TaskLogger) error {
+		log.Info("first line")
+		<-release
+		log.Warning("careful", AnnotationProperties{File: "a.go"})
+		log.Info("second line")
+		return nil
+	})
+	tasks.Go("second", func(ctx context.Context, log *TaskLogger) error {
+		defer close(release)
+		log.Info("other")
+		return errors.New("boom")
+	})
+	err := tasks.Wait()
+	require.Error(t, err)
+	tasksErr := &TasksError{}
+	require.True(t, errors.As(err, &tasksErr))
+	assert.Equal(t, 2, tasksErr.Total)
+	require.Len(t, tasksErr.Failed, 1)
+	assert.Equal(t, "second", tasksErr.Failed[0].Name)
+	assert.EqualError(t, err, "1 of 2 tasks failed: second")
+
+	out := b.String()
+	assert.Contains(t, out, "::group::first (")
+	assert.Contains(t, out, ")\nfirst line\n::warning file=a.go::careful\nsecond line\n::endgroup::\n")
+	assert.Contains(t, out, "::group::✗ second (")
+	assert.Contains(t, out, ")\nother\n::error::second%3A boom\n::endgroup::\n")
+	assert.Less(t, strings.Index(out, "second"), strings.Index(out, "first"))
+	assert.True(t, strings.HasSuffix(out, "::endgroup::\n✗ 1 of 2 tasks failed: second after 5s\n"), out)
+
+	results := tasks.Results()
+	require.Len(t, results, 2)
+	assert.Equal(t, "first", results[0].Name)
+	assert.NoError(t, results[0].Err)
+}
+
+func TestTasksLive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test only runs on unix with \\n line separator")
+	}
+	b := withInputs(t, nil)
+	withClock(t, time.Second)
+
+	tasks := NewTasks(context.Background(), TasksOptions{Live: true, Limit: 1})
+	tasks.Go("lint", func(ctx context.Context, log *TaskLogger) error {
+		log.Info("a\nb")
+		log.Debug("details")
+		return nil
+	})
+	tasks.Go("test", func(ctx context.Context, log *TaskLogger) error {
+		panic("unexpected")
+	})
+	err := tasks.Wait()
+	require.Error(t, err)
+	out := b.String()
+	assert.Contains(t, out, "[lint] a\n[lint] b\n::debug::[lint] details\n[lint] ✓ completed in 1s\n")
+	assert.Contains(t, out, "::error::[test] ✗ failed after 1s%3A task panicked%3A unexpected\n")
+	assert.NotContains(t, out, "::group::")
+}
+
+func TestTasksLimit(t *testing.T) {
+	withInputs(t, nil)
+	var (
+		mu      sync.Mutex
+		running int
+		max     int
+	)
+	tasks := NewTasks(context.Background(), TasksOptions{Limit: 2})
+	for i := 0; i < 10; i++ {
+		tasks.Go("task", func(ctx context.Context, log *TaskLogger) error {
+			mu.Lock()
+			running++
+			if running > max {
+				max = running
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		})
+	}
+	require.NoError(t, tasks.Wait())
+	assert.LessOrEqual(t, max, 2)
+	assert.Len(t, tasks.Results(), 10)
+}
@@ -2,7 +2,6 @@ package github
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -103,7 +102,7 @@ type ActionContext struct {
 }
 
 func noGitHubEvent(path string) {
-	fmt.Println(fmt.Sprintf("GITHUB_EVENT_PATH %s does not exist", path))
+	core.Infof("GITHUB_EVENT_PATH %s does not exist", path)
 }
 
 func getIndex(a []string, i int) string {
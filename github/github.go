@@ -65,11 +65,18 @@ type RepositoryFile struct {
 	Data     []byte
 }
 
-// DownloadSelectedRepositoryFiles downloads files from a given repository and granch, given that their name matches regarding the `include` function
+// DownloadSelectedRepositoryFiles downloads files from a given repository and granch, given that their name matches regarding the `include` function.
+// The download is interrupted when the action is cancelled, see core.Context.
 func DownloadSelectedRepositoryFiles(c *http.Client, owner, repo, branch string, include Matcher) map[string]RepositoryFile {
+	return DownloadSelectedRepositoryFilesContext(core.Context(), c, owner, repo, branch, include)
+}
+
+// DownloadSelectedRepositoryFilesContext downloads files from a given repository and branch, given that their name matches regarding the `include` function, until ctx is done.
+// No file is returned when the download is interrupted.
+func DownloadSelectedRepositoryFilesContext(ctx context.Context, c *http.Client, owner, repo, branch string, include Matcher) map[string]RepositoryFile {
 	u := fmt.Sprintf("https://api.github.com/repos/%s/%s/tarball/%s", owner, repo, branch)
 	core.Debugf("Downloading tarball for repo: %s", u)
-	req, err := http.NewRequest("GET", u, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
 		core.Warningf("failed to download repository: %v", err)
 		return nil
@@ -0,0 +1,225 @@
+// Package metadata parses the action.yml metadata file of an action and enforces it at runtime:
+// declared input defaults, required and deprecated inputs as well as declared outputs.
+// See https://docs.github.com/en/actions/creating-actions/metadata-syntax-for-github-actions
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/actions-go/toolkit/core"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the supported names of the action metadata file, by order of precedence
+var FileNames = []string{"action.yml", "action.yaml"}
+
+// Action holds the metadata of an action
+type Action struct {
+	Name        string            `yaml:"name"`
+	Author      string            `yaml:"author"`
+	Description string            `yaml:"description"`
+	Inputs      map[string]Input  `yaml:"inputs"`
+	Outputs     map[string]Output `yaml:"outputs"`
+	Runs        Runs              `yaml:"runs"`
+	Branding    Branding          `yaml:"branding"`
+}
+
+// Input describes an input accepted by the action
+type Input struct {
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+	// Default is the value used when the input is not provided by the workflow
+	Default string `yaml:"default"`
+	// DeprecationMessage is displayed as a warning when the input is used
+	DeprecationMessage string `yaml:"deprecationMessage"`
+}
+
+// Output describes an output set by the action
+type Output struct {
+	Description string `yaml:"description"`
+	// Value is only used by composite actions
+	Value string `yaml:"value"`
+}
+
+// Runs describes how the action is executed
+type Runs struct {
+	Using string `yaml:"using"`
+	// Main, Pre and Post are used by javascript actions
+	Main   string `yaml:"main"`
+	Pre    string `yaml:"pre"`
+	PreIf  string `yaml:"pre-if"`
+	Post   string `yaml:"post"`
+	PostIf string `yaml:"post-if"`
+	// Image and entrypoints are used by docker actions
+	Image          string            `yaml:"image"`
+	Entrypoint     string            `yaml:"entrypoint"`
+	PreEntrypoint  string            `yaml:"pre-entrypoint"`
+	PostEntrypoint string            `yaml:"post-entrypoint"`
+	Args           []string          `yaml:"args"`
+	Env            map[string]string `yaml:"env"`
+	// Steps are used by composite actions
+	Steps []map[string]interface{} `yaml:"steps"`
+}
+
+// Branding describes how the action is displayed in the marketplace
+type Branding struct {
+	Icon  string `yaml:"icon"`
+	Color string `yaml:"color"`
+}
+
+// Parse parses the content of an action.yml file
+func Parse(data []byte) (*Action, error) {
+	a := &Action{}
+	if err := yaml.Unmarshal(data, a); err != nil {
+		return nil, fmt.Errorf("invalid action metadata: %w", err)
+	}
+	if a.Name == "" {
+		return nil, fmt.Errorf("invalid action metadata: missing name")
+	}
+	if a.Runs.Using == "" {
+		return nil, fmt.Errorf("invalid action metadata: missing runs.using")
+	}
+	return a, nil
+}
+
+// Load reads and parses an action metadata file
+func Load(path string) (*Action, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return a, nil
+}
+
+// LoadDir reads and parses the action.yml, or action.yaml, file located in dir
+func LoadDir(dir string) (*Action, error) {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+	return nil, fmt.Errorf("unable to find any of %s in %s", strings.Join(FileNames, ", "), dir)
+}
+
+// Input returns the declared input matching name. Names are matched case insensitively like the runner does
+func (a *Action) Input(name string) (Input, bool) {
+	if input, ok := a.Inputs[name]; ok {
+		return input, true
+	}
+	for n, input := range a.Inputs {
+		if strings.EqualFold(n, name) {
+			return input, true
+		}
+	}
+	return Input{}, false
+}
+
+// Output returns the declared output matching name
+func (a *Action) Output(name string) (Output, bool) {
+	if output, ok := a.Outputs[name]; ok {
+		return output, true
+	}
+	for n, output := range a.Outputs {
+		if strings.EqualFold(n, name) {
+			return output, true
+		}
+	}
+	return Output{}, false
+}
+
+// ValidateInputs checks all required inputs are provided, and returns a *core.BindError listing the missing ones
+func (a *Action) ValidateInputs() error {
+	names := make([]string, 0, len(a.Inputs))
+	for name := range a.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs := []*core.InputError{}
+	for _, name := range names {
+		if !a.Inputs[name].Required {
+			continue
+		}
+		if _, err := core.GetInputE(name, core.InputOptions{Required: true}); err != nil {
+			inputErr := &core.InputError{}
+			if errors.As(err, &inputErr) {
+				errs = append(errs, inputErr)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return &core.BindError{Errors: errs}
+	}
+	return nil
+}
+
+// Options controls how the metadata is enforced
+type Options struct {
+	// Strict rejects outputs that are not declared in the metadata instead of warning about them
+	Strict bool
+}
+
+// Install hooks the metadata into core input and output functions so that:
+//   - declared defaults are applied to inputs that are not provided, typically when running outside the runner
+//   - required inputs that are not provided are reported as errors
+//   - deprecated inputs and undeclared inputs are warned about
+//   - undeclared outputs are warned about, or rejected in strict mode
+//
+// The returned function removes the hooks.
+func (a *Action) Install(options ...Options) func() {
+	opts := Options{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	warned := &sync.Map{}
+	warnOnce := func(key, format string, args ...interface{}) {
+		if _, loaded := warned.LoadOrStore(key, true); !loaded {
+			core.Warningf(format, args...)
+		}
+	}
+	core.SetInputHook(func(name, value string, found bool) (string, bool, error) {
+		input, ok := a.Input(name)
+		if !ok {
+			warnOnce("input/"+name, "Input '%s' is not declared in the action metadata", name)
+			return value, found, nil
+		}
+		if found && input.DeprecationMessage != "" && value != input.Default {
+			warnOnce("deprecated/"+name, "Input '%s' has been deprecated with message: %s", name, input.DeprecationMessage)
+		}
+		if !found && input.Default != "" {
+			if strings.Contains(input.Default, "${{") {
+				core.Debugf("not applying default value of input %s: expressions are only evaluated by the runner", name)
+			} else {
+				value, found = input.Default, true
+			}
+		}
+		if input.Required && strings.TrimSpace(value) == "" {
+			return value, found, &core.InputError{Name: name, Err: core.ErrInputRequired}
+		}
+		return value, found, nil
+	})
+	core.SetOutputHook(func(name string) error {
+		if _, ok := a.Output(name); ok {
+			return nil
+		}
+		if opts.Strict {
+			return fmt.Errorf("output %s is not declared in the action metadata", name)
+		}
+		warnOnce("output/"+name, "Output '%s' is not declared in the action metadata", name)
+		return nil
+	})
+	return func() {
+		core.SetInputHook(nil)
+		core.SetOutputHook(nil)
+	}
+}
@@ -0,0 +1,136 @@
+package metadata_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/actions-go/toolkit/core"
+	"github.com/actions-go/toolkit/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureStdout(t *testing.T) *bytes.Buffer {
+	b := bytes.NewBuffer(nil)
+	core.SetStdout(b)
+	t.Cleanup(func() { core.SetStdout(os.Stdout) })
+	return b
+}
+
+func TestLoad(t *testing.T) {
+	a, err := metadata.Load("test_action.yml")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello world", a.Name)
+	assert.Equal(t, metadata.Input{Description: "Who to greet", Required: true, Default: "World"}, a.Inputs["who-to-greet"])
+	assert.Equal(t, "3", a.Inputs["retries"].Default)
+	assert.Equal(t, "Use who-to-greet instead", a.Inputs["name"].DeprecationMessage)
+	assert.Equal(t, metadata.Output{Description: "The time we greeted you"}, a.Outputs["time"])
+	assert.Equal(t, metadata.Runs{Using: "node20", Main: "main.js", Post: "post.js", PostIf: "success()"}, a.Runs)
+	assert.Equal(t, metadata.Branding{Icon: "sun", Color: "yellow"}, a.Branding)
+
+	_, ok := a.Input("WHO-TO-GREET")
+	assert.True(t, ok)
+	_, ok = a.Output("unknown")
+	assert.False(t, ok)
+
+	_, err = metadata.Load("does-not-exist.yml")
+	assert.Error(t, err)
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	_, err := metadata.LoadDir(dir)
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "action.yaml"), []byte("name: yaml\nruns:\n  using: docker\n  image: Dockerfile\n"), 0644))
+	a, err := metadata.LoadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "yaml", a.Name)
+	assert.Equal(t, "Dockerfile", a.Runs.Image)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := metadata.Parse([]byte("name: ["))
+	assert.Error(t, err)
+	_, err = metadata.Parse([]byte("runs:\n  using: node20\n"))
+	assert.EqualError(t, err, "invalid action metadata: missing name")
+	_, err = metadata.Parse([]byte("name: test\n"))
+	assert.EqualError(t, err, "invalid action metadata: missing runs.using")
+}
+
+func TestInstall(t *testing.T) {
+	a, err := metadata.Load("test_action.yml")
+	require.NoError(t, err)
+	out := captureStdout(t)
+	t.Setenv("INPUT_TOKEN", "")
+	t.Setenv("INPUT_NAME", "octocat")
+	uninstall := a.Install()
+	t.Cleanup(uninstall)
+
+	assert.Equal(t, "World", core.GetInputOrDefault("who-to-greet", ""))
+	assert.Equal(t, "3", core.GetInputOrDefault("retries", ""))
+	_, ok := core.GetInput("github-token")
+	assert.False(t, ok, "expressions can't be evaluated outside of the runner")
+
+	out.Reset()
+	assert.Equal(t, "octocat", core.GetInputOrDefault("name", ""))
+	core.GetInput("name")
+	assert.Equal(t, "::warning::Input 'name' has been deprecated with message%3A Use who-to-greet instead\n", out.String())
+
+	out.Reset()
+	core.GetInput("undeclared")
+	core.GetInput("undeclared")
+	assert.Contains(t, out.String(), "::warning::Input 'undeclared' is not declared in the action metadata\n")
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("::warning::")))
+
+	_, err = core.GetInputE("token")
+	assert.True(t, errors.Is(err, core.ErrInputRequired))
+	assert.EqualError(t, err, "input token: input required and not supplied")
+
+	t.Cleanup(core.ResetState)
+	assert.Equal(t, core.StatusSuccess, core.Status())
+	out.Reset()
+	core.GetInput("token")
+	assert.Equal(t, "::error::input token%3A input required and not supplied\n", out.String())
+	assert.Equal(t, core.StatusFailed, core.Status())
+
+	err = a.ValidateInputs()
+	bindErr := &core.BindError{}
+	require.True(t, errors.As(err, &bindErr))
+	require.Len(t, bindErr.Errors, 1)
+	assert.Equal(t, "token", bindErr.Errors[0].Name)
+
+	uninstall()
+	v, ok := core.GetInput("who-to-greet")
+	assert.False(t, ok)
+	assert.Equal(t, "", v)
+}
+
+func TestInstallOutputs(t *testing.T) {
+	a, err := metadata.Load("test_action.yml")
+	require.NoError(t, err)
+	out := captureStdout(t)
+	output := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, os.WriteFile(output, nil, 0644))
+	t.Setenv(core.GitHubOutputFilePathEnvName, output)
+
+	t.Cleanup(a.Install())
+	core.SetOutput("undeclared", "value")
+	assert.Contains(t, out.String(), "::warning::Output 'undeclared' is not declared in the action metadata\n")
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "undeclared<<")
+
+	require.NoError(t, os.WriteFile(output, nil, 0644))
+	out.Reset()
+	t.Cleanup(a.Install(metadata.Options{Strict: true}))
+	core.SetOutput("undeclared", "value")
+	core.SetOutput("time", "now")
+	assert.Contains(t, out.String(), "::error::unable to set output undeclared%3A output undeclared is not declared in the action metadata\n")
+	content, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "undeclared<<")
+	assert.Contains(t, string(content), "time<<")
+}